@@ -1,6 +1,10 @@
 package goNest
 
-import "context"
+import (
+	"context"
+	"strconv"
+	"strings"
+)
 
 func GetCtxStringValue(ctx context.Context, key interface{}) string {
 	if val := ctx.Value(key); val != nil {
@@ -13,11 +17,20 @@ func GetCtxStringValue(ctx context.Context, key interface{}) string {
 	return ""
 }
 
+// GetCtxIntValue : Read an integer from the context, numeric strings such as query params are parsed
 func GetCtxIntValue(ctx context.Context, key interface{}) int {
 	if val := ctx.Value(key); val != nil {
-		// Try to assert the value as a string
-		if intVal, ok := val.(int); ok {
+		switch intVal := val.(type) {
+		case int:
 			return intVal
+		case int32:
+			return int(intVal)
+		case int64:
+			return int(intVal)
+		case string:
+			if parsed, err := strconv.Atoi(strings.TrimSpace(intVal)); err == nil {
+				return parsed
+			}
 		}
 	}
 	// Return 0 if nil or not int value
@@ -0,0 +1,278 @@
+package mongora
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// BulkOptions : Batching and ordering for InsertMany and BulkWrite
+type BulkOptions struct {
+	// Ordered stops at the first failure, later items are reported as skipped
+	Ordered bool
+
+	// BatchSize is the number of items sent per round trip, defaults to 1000
+	BatchSize int
+}
+
+// BulkItemStatus : Outcome of a single item in a bulk operation
+type BulkItemStatus string
+
+const (
+	BulkSucceeded BulkItemStatus = "succeeded"
+	BulkUpserted  BulkItemStatus = "upserted"
+	BulkFailed    BulkItemStatus = "failed"
+	BulkSkipped   BulkItemStatus = "skipped"
+)
+
+// BulkItemResult : Outcome of the item at Index in the caller's input
+type BulkItemResult struct {
+	Index  int            `json:"index"`
+	Status BulkItemStatus `json:"status"`
+	Id     interface{}    `json:"id,omitempty"`
+	Err    error          `json:"-"`
+}
+
+// BulkResult : Totals across every batch plus one entry per input item
+type BulkResult struct {
+	InsertedCount int64            `json:"inserted_count"`
+	MatchedCount  int64            `json:"matched_count"`
+	ModifiedCount int64            `json:"modified_count"`
+	DeletedCount  int64            `json:"deleted_count"`
+	UpsertedCount int64            `json:"upserted_count"`
+	Items         []BulkItemResult `json:"items"`
+}
+
+var defaultBulkBatchSize = 1000
+
+// Failed : Items that were rejected by the server or never sent
+func (r *BulkResult) Failed() []BulkItemResult {
+	var failed []BulkItemResult
+	for _, item := range r.Items {
+		if item.Status == BulkFailed || item.Status == BulkSkipped {
+			failed = append(failed, item)
+		}
+	}
+	return failed
+}
+
+// InsertMany : Insert documents in batches, the returned error joins every batch failure
+func InsertMany(ctx context.Context, collection *mongo.Collection, documents []interface{}, opts ...BulkOptions) (*BulkResult, error) {
+	bulkOpts := resolveBulkOptions(opts)
+
+	documents, err := stampInsertMany(ctx, collection, documents)
+	if err != nil {
+		return nil, err
+	}
+
+	return runBulkBatches(ctx, collection, len(documents), bulkOpts, func(ctx context.Context, start int, end int, result *BulkResult) error {
+		insertOpts := options.InsertMany().SetOrdered(bulkOpts.Ordered)
+		insertResult, err := collection.InsertMany(ctx, documents[start:end], insertOpts)
+
+		for i := start; i < end; i++ {
+			result.Items[i].Status = BulkSucceeded
+			if insertResult != nil && i-start < len(insertResult.InsertedIDs) {
+				result.Items[i].Id = insertResult.InsertedIDs[i-start]
+			}
+		}
+
+		succeeded := int64(end-start) - markBulkErrors(err, start, end, bulkOpts.Ordered, result)
+		result.InsertedCount += succeeded
+		return err
+	})
+}
+
+// BulkWrite : Run insert, update, replace, upsert and delete models in batches with per-item results
+func BulkWrite(ctx context.Context, collection *mongo.Collection, models []mongo.WriteModel, opts ...BulkOptions) (*BulkResult, error) {
+	bulkOpts := resolveBulkOptions(opts)
+
+	models, err := stampWriteModels(ctx, collection, models)
+	if err != nil {
+		return nil, err
+	}
+	models, insertIds, err := assignInsertIds(models)
+	if err != nil {
+		return nil, err
+	}
+
+	return runBulkBatches(ctx, collection, len(models), bulkOpts, func(ctx context.Context, start int, end int, result *BulkResult) error {
+		writeOpts := options.BulkWrite().SetOrdered(bulkOpts.Ordered)
+		writeResult, err := collection.BulkWrite(ctx, models[start:end], writeOpts)
+
+		for i := start; i < end; i++ {
+			result.Items[i].Status = BulkSucceeded
+			result.Items[i].Id = insertIds[i]
+		}
+
+		if writeResult != nil {
+			result.InsertedCount += writeResult.InsertedCount
+			result.MatchedCount += writeResult.MatchedCount
+			result.ModifiedCount += writeResult.ModifiedCount
+			result.DeletedCount += writeResult.DeletedCount
+			result.UpsertedCount += writeResult.UpsertedCount
+
+			for index, id := range writeResult.UpsertedIDs {
+				result.Items[start+int(index)].Status = BulkUpserted
+				result.Items[start+int(index)].Id = id
+			}
+		}
+
+		markBulkErrors(err, start, end, bulkOpts.Ordered, result)
+		return err
+	})
+}
+
+func resolveBulkOptions(opts []BulkOptions) BulkOptions {
+	var bulkOpts BulkOptions
+	if len(opts) > 0 {
+		bulkOpts = opts[0]
+	}
+	if bulkOpts.BatchSize <= 0 {
+		bulkOpts.BatchSize = defaultBulkBatchSize
+	}
+	return bulkOpts
+}
+
+// runBulkBatches : Split the input into batches, each batch gets its own timeout so large imports don't starve
+func runBulkBatches(ctx context.Context, collection *mongo.Collection, count int, bulkOpts BulkOptions,
+	runBatch func(ctx context.Context, start int, end int, result *BulkResult) error) (*BulkResult, error) {
+	result := &BulkResult{Items: make([]BulkItemResult, count)}
+	for i := range result.Items {
+		result.Items[i] = BulkItemResult{Index: i, Status: BulkSkipped}
+	}
+
+	var errs []error
+	for start := 0; start < count; start += bulkOpts.BatchSize {
+		end := min(start+bulkOpts.BatchSize, count)
+
+		batchCtx, cancel := withTimeout(ctx, collection)
+		err := runBatch(batchCtx, start, end, result)
+		cancel()
+
+		if err != nil {
+			errs = append(errs, translateError(err))
+			if bulkOpts.Ordered {
+				break
+			}
+		}
+	}
+
+	return result, errors.Join(errs...)
+}
+
+// markBulkErrors : Flag the items named in a BulkWriteException, any other error fails the whole batch.
+// Returns the number of items in the batch that did not succeed.
+func markBulkErrors(err error, start int, end int, ordered bool, result *BulkResult) int64 {
+	if err == nil {
+		return 0
+	}
+
+	var bulkException mongo.BulkWriteException
+	if !errors.As(err, &bulkException) || len(bulkException.WriteErrors) == 0 {
+		for i := start; i < end; i++ {
+			result.Items[i].Status = BulkFailed
+			result.Items[i].Id = nil
+			result.Items[i].Err = translateError(err)
+		}
+		return int64(end - start)
+	}
+
+	failures := int64(0)
+	firstFailure := end
+	for _, writeError := range bulkException.WriteErrors {
+		index := start + writeError.Index
+		result.Items[index].Status = BulkFailed
+		result.Items[index].Id = nil
+		result.Items[index].Err = translateError(mongo.WriteException{WriteErrors: mongo.WriteErrors{writeError.WriteError}})
+		failures++
+		firstFailure = min(firstFailure, index)
+	}
+
+	// Ordered writes stop at the first failure, nothing after it reached the server
+	if ordered {
+		for i := firstFailure + 1; i < end; i++ {
+			if result.Items[i].Status != BulkFailed {
+				result.Items[i].Status = BulkSkipped
+				result.Items[i].Id = nil
+				failures++
+			}
+		}
+	}
+
+	return failures
+}
+
+// stampInsertMany : Apply the timestamp policy to every document without touching the caller's slice
+func stampInsertMany(ctx context.Context, collection *mongo.Collection, documents []interface{}) ([]interface{}, error) {
+	stamped := make([]interface{}, len(documents))
+	for i, document := range documents {
+		var err error
+		if stamped[i], err = stampInsert(ctx, collection, document); err != nil {
+			return nil, fmt.Errorf("item %d: %w", i, err)
+		}
+	}
+	return stamped, nil
+}
+
+// stampWriteModels : Apply the timestamp policy to insert and update models, replacements are sent as given
+func stampWriteModels(ctx context.Context, collection *mongo.Collection, models []mongo.WriteModel) ([]mongo.WriteModel, error) {
+	stamped := make([]mongo.WriteModel, len(models))
+	for i, model := range models {
+		var err error
+		switch m := model.(type) {
+		case *mongo.InsertOneModel:
+			clone := *m
+			clone.Document, err = stampInsert(ctx, collection, m.Document)
+			stamped[i] = &clone
+		case *mongo.UpdateOneModel:
+			clone := *m
+			clone.Update, err = stampUpdate(ctx, collection, m.Update, m.Upsert != nil && *m.Upsert)
+			stamped[i] = &clone
+		case *mongo.UpdateManyModel:
+			clone := *m
+			clone.Update, err = stampUpdate(ctx, collection, m.Update, m.Upsert != nil && *m.Upsert)
+			stamped[i] = &clone
+		default:
+			stamped[i] = model
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("item %d: %w", i, err)
+		}
+	}
+	return stamped, nil
+}
+
+// assignInsertIds : Give insert models without an _id a new ObjectID, BulkWriteResult does not report inserted ids.
+// Returns the _id of every insert model by position, nil for the other models.
+func assignInsertIds(models []mongo.WriteModel) ([]mongo.WriteModel, []interface{}, error) {
+	ids := make([]interface{}, len(models))
+	for i, model := range models {
+		insert, ok := model.(*mongo.InsertOneModel)
+		if !ok {
+			continue
+		}
+
+		doc, err := toBsonD(insert.Document)
+		if err != nil {
+			return nil, nil, fmt.Errorf("item %d: %w", i, err)
+		}
+		for _, elem := range doc {
+			if elem.Key == "_id" {
+				ids[i] = elem.Value
+			}
+		}
+		if ids[i] == nil {
+			ids[i] = primitive.NewObjectID()
+			doc = append(bson.D{{Key: "_id", Value: ids[i]}}, doc...)
+		}
+
+		// stampWriteModels already cloned the model
+		insert.Document = doc
+	}
+	return models, ids, nil
+}
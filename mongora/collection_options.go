@@ -0,0 +1,103 @@
+package mongora
+
+import (
+	"context"
+	"go.mongodb.org/mongo-driver/mongo"
+	"sync"
+	"time"
+)
+
+// CollectionOptions : Per-collection behaviour applied by the mongora helpers
+type CollectionOptions struct {
+	// Timeout applied when the caller's context has no deadline, 0 falls back to the package default
+	Timeout time.Duration
+
+	// FilterFields whitelists the fields ParseCollectionFilter accepts from the query string
+	FilterFields FilterFields
+
+	// SortFields lists the fields clients may sort on, an empty list allows any field
+	SortFields []string
+
+	// ProjectionFields lists the fields clients may project, an empty list allows any field
+	ProjectionFields []string
+
+	// SoftDelete makes deletes set deleted_at/deleted_by and hides those documents from reads
+	SoftDelete bool
+
+	// Timestamps stamps created_at on insert and updated_at on every update
+	Timestamps bool
+
+	// AuditFields records created_by/updated_by from the actor stored with WithActor
+	AuditFields bool
+
+	// VersionField enables optimistic concurrency, the field starts at 1 and is incremented on every update
+	VersionField string
+}
+
+var defaultTimeout = 10 * time.Second
+
+var collectionOptions = map[string]CollectionOptions{}
+var collectionOptionsMutex sync.RWMutex
+
+// SetDefaultTimeout : Timeout applied to every operation whose context has no deadline, 0 disables it
+func SetDefaultTimeout(timeout time.Duration) { defaultTimeout = timeout }
+func GetDefaultTimeout() time.Duration        { return defaultTimeout }
+
+// ConfigureCollection : Register the options used whenever mongora operates on the collection
+func ConfigureCollection(collection *mongo.Collection, opts CollectionOptions) {
+	collectionOptionsMutex.Lock()
+	defer collectionOptionsMutex.Unlock()
+
+	collectionOptions[collectionKey(collection)] = opts
+}
+
+// GetCollectionOptions : Return the options registered for the collection, or the zero value
+func GetCollectionOptions(collection *mongo.Collection) CollectionOptions {
+	collectionOptionsMutex.RLock()
+	defer collectionOptionsMutex.RUnlock()
+
+	return collectionOptions[collectionKey(collection)]
+}
+
+// SetCollectionTimeout : Shortcut to change only the timeout of a collection
+func SetCollectionTimeout(collection *mongo.Collection, timeout time.Duration) {
+	collectionOptionsMutex.Lock()
+	defer collectionOptionsMutex.Unlock()
+
+	key := collectionKey(collection)
+	opts := collectionOptions[key]
+	opts.Timeout = timeout
+	collectionOptions[key] = opts
+}
+
+// collectionKey : Collections are identified by their fully qualified "database.collection" name
+func collectionKey(collection *mongo.Collection) string {
+	return collection.Database().Name() + "." + collection.Name()
+}
+
+// collectionTimeout : Resolve the timeout for the collection, falling back to the package default
+func collectionTimeout(collection *mongo.Collection) time.Duration {
+	if timeout := GetCollectionOptions(collection).Timeout; timeout > 0 {
+		return timeout
+	}
+
+	return defaultTimeout
+}
+
+// withTimeout : Derive an operation context, the caller's deadline always wins over the timeout policy
+func withTimeout(ctx context.Context, collection *mongo.Collection) (context.Context, context.CancelFunc) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	if _, hasDeadline := ctx.Deadline(); hasDeadline {
+		return context.WithCancel(ctx)
+	}
+
+	timeout := collectionTimeout(collection)
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+
+	return context.WithTimeout(ctx, timeout)
+}
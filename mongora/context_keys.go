@@ -0,0 +1,23 @@
+package mongora
+
+import "context"
+
+// contextKey : Unexported key type so mongora values never collide with other packages' context values
+type contextKey int
+
+const (
+	deletedScopeKey contextKey = iota
+	actorKey
+	queryParamsKey
+)
+
+// WithActor : Store the user performing the operation, recorded in deleted_by and the audit fields
+func WithActor(ctx context.Context, actor interface{}) context.Context {
+	return context.WithValue(ctx, actorKey, actor)
+}
+
+// ActorFromContext : Return the actor stored with WithActor
+func ActorFromContext(ctx context.Context) (interface{}, bool) {
+	actor := ctx.Value(actorKey)
+	return actor, actor != nil
+}
@@ -0,0 +1,216 @@
+package mongora
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// Sentinel errors returned by the mongora helpers, compare them with errors.Is
+var (
+	ErrNotFound     = errors.New("mongora: document not found")
+	ErrInvalidID    = errors.New("mongora: invalid object id")
+	ErrDuplicateKey = errors.New("mongora: duplicate key")
+	ErrValidation   = errors.New("mongora: validation failed")
+	ErrTimeout      = errors.New("mongora: operation timed out")
+
+	// ErrVersionConflict : The stored document version moved on since it was read
+	ErrVersionConflict = errors.New("mongora: version conflict")
+
+	// ErrInvalidCursor : The continuation token is malformed, tampered with or was issued for another sort order
+	ErrInvalidCursor = errors.New("mongora: invalid continuation token")
+
+	// ErrUnsupportedMediaType : The request body is not JSON
+	ErrUnsupportedMediaType = errors.New("mongora: unsupported media type")
+
+	// ErrBodyTooLarge : The request body is over the configured size limit
+	ErrBodyTooLarge = errors.New("mongora: request body too large")
+)
+
+// DuplicateKeyError : A unique index rejected the write, use errors.As to inspect the offending key
+type DuplicateKeyError struct {
+	KeyPattern bson.M
+	KeyValue   bson.M
+	Err        error
+}
+
+func (e *DuplicateKeyError) Error() string {
+	if len(e.KeyPattern) == 0 {
+		return ErrDuplicateKey.Error()
+	}
+
+	keys := make([]string, 0, len(e.KeyPattern))
+	for key := range e.KeyPattern {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return fmt.Sprintf("%s on %s", ErrDuplicateKey.Error(), strings.Join(keys, ", "))
+}
+
+func (e *DuplicateKeyError) Is(target error) bool { return target == ErrDuplicateKey }
+func (e *DuplicateKeyError) Unwrap() error        { return e.Err }
+
+// FieldError : A single field that failed validation
+type FieldError struct {
+	Field   string `json:"field"`
+	Tag     string `json:"tag,omitempty"`
+	Param   string `json:"param,omitempty"`
+	Message string `json:"message"`
+}
+
+// ValidationError : Request or document validation failure with per-field details
+type ValidationError struct {
+	Message string
+	Fields  []FieldError
+
+	// cause keeps the validator output so LocalizeError can translate it again
+	cause error
+}
+
+func (e *ValidationError) Error() string {
+	message := e.Message
+	if message == "" {
+		message = ErrValidation.Error()
+	}
+
+	if len(e.Fields) == 0 {
+		return message
+	}
+
+	details := make([]string, 0, len(e.Fields))
+	for _, field := range e.Fields {
+		details = append(details, field.Message)
+	}
+	return fmt.Sprintf("%s: %s", message, strings.Join(details, "; "))
+}
+
+func (e *ValidationError) Is(target error) bool { return target == ErrValidation }
+func (e *ValidationError) Unwrap() error        { return e.cause }
+
+// HTTPStatus : Map a mongora error to the HTTP status code an API should respond with
+func HTTPStatus(err error) int {
+	switch {
+	case err == nil:
+		return http.StatusOK
+	case errors.Is(err, ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ErrInvalidID), errors.Is(err, ErrInvalidCursor):
+		return http.StatusBadRequest
+	case errors.Is(err, ErrDuplicateKey), errors.Is(err, ErrVersionConflict):
+		return http.StatusConflict
+	case errors.Is(err, ErrValidation):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, ErrUnsupportedMediaType):
+		return http.StatusUnsupportedMediaType
+	case errors.Is(err, ErrBodyTooLarge):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, ErrTimeout):
+		return http.StatusGatewayTimeout
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// ErrorResponse : JSON error envelope written by the HTTP helpers, {"error": {"status": 404, "message": "..."}}
+type ErrorResponse struct {
+	Error ErrorBody `json:"error"`
+}
+
+// ErrorBody : Status, message and, for validation failures, the failing fields
+type ErrorBody struct {
+	Status  int          `json:"status"`
+	Message string       `json:"message"`
+	Fields  []FieldError `json:"fields,omitempty"`
+}
+
+// NewErrorResponse : Build the envelope for err, internal errors are reported without their details
+func NewErrorResponse(err error) ErrorResponse {
+	status := HTTPStatus(err)
+	body := ErrorBody{Status: status, Message: http.StatusText(status)}
+	if status == http.StatusInternalServerError || err == nil {
+		return ErrorResponse{Error: body}
+	}
+
+	var validationError *ValidationError
+	if errors.As(err, &validationError) {
+		body.Message = validationError.Message
+		body.Fields = validationError.Fields
+		if body.Message == "" {
+			body.Message = ErrValidation.Error()
+		}
+		return ErrorResponse{Error: body}
+	}
+
+	body.Message = err.Error()
+	return ErrorResponse{Error: body}
+}
+
+// wrappedError : Reports the sentinel message while keeping the driver error reachable through errors.Is/As
+type wrappedError struct {
+	sentinel error
+	cause    error
+}
+
+func (e *wrappedError) Error() string   { return e.sentinel.Error() + ": " + e.cause.Error() }
+func (e *wrappedError) Unwrap() []error { return []error{e.sentinel, e.cause} }
+
+// translateError : Convert raw driver errors into the mongora error model
+func translateError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	switch {
+	case errors.Is(err, mongo.ErrNoDocuments):
+		return &wrappedError{sentinel: ErrNotFound, cause: err}
+	case mongo.IsDuplicateKeyError(err):
+		keyPattern, keyValue := duplicateKeyDetails(err)
+		return &DuplicateKeyError{KeyPattern: keyPattern, KeyValue: keyValue, Err: err}
+	case errors.Is(err, context.DeadlineExceeded), mongo.IsTimeout(err):
+		return &wrappedError{sentinel: ErrTimeout, cause: err}
+	}
+
+	return err
+}
+
+// duplicateKeyDetails : Pull keyPattern and keyValue out of the raw server error
+func duplicateKeyDetails(err error) (bson.M, bson.M) {
+	var raws []bson.Raw
+
+	var writeException mongo.WriteException
+	var bulkException mongo.BulkWriteException
+	var commandError mongo.CommandError
+	switch {
+	case errors.As(err, &writeException):
+		for _, writeError := range writeException.WriteErrors {
+			raws = append(raws, writeError.Raw)
+		}
+	case errors.As(err, &bulkException):
+		for _, writeError := range bulkException.WriteErrors {
+			raws = append(raws, writeError.Raw)
+		}
+	case errors.As(err, &commandError):
+		raws = append(raws, commandError.Raw)
+	}
+
+	for _, raw := range raws {
+		if len(raw) == 0 {
+			continue
+		}
+
+		var details struct {
+			KeyPattern bson.M `bson:"keyPattern"`
+			KeyValue   bson.M `bson:"keyValue"`
+		}
+		if bson.Unmarshal(raw, &details) == nil && len(details.KeyPattern) > 0 {
+			return details.KeyPattern, details.KeyValue
+		}
+	}
+
+	return nil, nil
+}
@@ -0,0 +1,183 @@
+package mongora
+
+import (
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FieldType : Type a filterable field's query string value is converted to
+type FieldType int
+
+const (
+	StringField FieldType = iota
+	IntField
+	FloatField
+	BoolField
+	DateField
+	ObjectIdField
+)
+
+// FilterFields : Whitelist of filterable fields and their types, keyed by the document field path
+type FilterFields map[string]FieldType
+
+// filterOperators : Query string operator to MongoDB operator, operators marked true take a comma separated list
+var filterOperators = map[string]struct {
+	mongoOperator string
+	isList        bool
+}{
+	"eq":     {"$eq", false},
+	"ne":     {"$ne", false},
+	"gt":     {"$gt", false},
+	"gte":    {"$gte", false},
+	"lt":     {"$lt", false},
+	"lte":    {"$lte", false},
+	"in":     {"$in", true},
+	"nin":    {"$nin", true},
+	"all":    {"$all", true},
+	"exists": {"$exists", false},
+}
+
+// ParseFilter : Compile query params such as price[gte]=10&status[in]=a,b into a bson.D filter.
+// Params that are not whitelisted are ignored so paging and projection params can share the query string.
+func ParseFilter(queryParams url.Values, fields FilterFields) (bson.D, error) {
+	filter := bson.D{}
+	conditions := map[string]bson.D{}
+	var fieldOrder []string
+	var fieldErrors []FieldError
+
+	// Sort the keys so the generated filter is deterministic
+	keys := make([]string, 0, len(queryParams))
+	for key := range queryParams {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		field, operator, err := parseFilterKey(key)
+		fieldType, allowed := fields[field]
+		if !allowed {
+			continue
+		}
+		if err != nil {
+			fieldErrors = append(fieldErrors, FieldError{Field: key, Message: err.Error()})
+			continue
+		}
+
+		op, known := filterOperators[operator]
+		if !known {
+			fieldErrors = append(fieldErrors, FieldError{Field: field, Tag: operator, Message: fmt.Sprintf("unsupported filter operator '%s' on '%s'", operator, field)})
+			continue
+		}
+
+		for _, raw := range queryParams[key] {
+			value, err := convertFilterValue(raw, fieldType, op.mongoOperator, op.isList)
+			if err != nil {
+				fieldErrors = append(fieldErrors, FieldError{Field: field, Tag: operator, Param: raw, Message: fmt.Sprintf("invalid value for '%s': %v", field, err)})
+				continue
+			}
+
+			if _, seen := conditions[field]; !seen {
+				fieldOrder = append(fieldOrder, field)
+			}
+			conditions[field] = append(conditions[field], bson.E{Key: op.mongoOperator, Value: value})
+		}
+	}
+
+	if len(fieldErrors) > 0 {
+		return nil, &ValidationError{Message: "Invalid filter", Fields: fieldErrors}
+	}
+
+	for _, field := range fieldOrder {
+		condition := conditions[field]
+
+		// Keep plain equality readable as {field: value}
+		if len(condition) == 1 && condition[0].Key == "$eq" {
+			filter = append(filter, bson.E{Key: field, Value: condition[0].Value})
+		} else {
+			filter = append(filter, bson.E{Key: field, Value: condition})
+		}
+	}
+
+	return filter, nil
+}
+
+// ParseCollectionFilter : ParseFilter using the FilterFields registered with ConfigureCollection
+func ParseCollectionFilter(queryParams url.Values, collection *mongo.Collection) (bson.D, error) {
+	return ParseFilter(queryParams, GetCollectionOptions(collection).FilterFields)
+}
+
+// parseFilterKey : Split "price[gte]" into its field and operator, a bare key means equality.
+// A malformed key still returns its field so params outside the whitelist can be ignored.
+func parseFilterKey(key string) (string, string, error) {
+	start := strings.Index(key, "[")
+	if start == -1 {
+		return key, "eq", nil
+	}
+
+	if !strings.HasSuffix(key, "]") || start == 0 {
+		return key[:start], "", fmt.Errorf("malformed filter key '%s'", key)
+	}
+
+	return key[:start], key[start+1 : len(key)-1], nil
+}
+
+// convertFilterValue : Convert the raw query string value into the whitelisted type
+func convertFilterValue(raw string, fieldType FieldType, mongoOperator string, isList bool) (interface{}, error) {
+	if mongoOperator == "$exists" {
+		return strconv.ParseBool(raw)
+	}
+
+	if !isList {
+		return convertFieldValue(raw, fieldType)
+	}
+
+	values := bson.A{}
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		value, err := convertFieldValue(part, fieldType)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
+
+func convertFieldValue(raw string, fieldType FieldType) (interface{}, error) {
+	switch fieldType {
+	case IntField:
+		return strconv.ParseInt(raw, 10, 64)
+	case FloatField:
+		return strconv.ParseFloat(raw, 64)
+	case BoolField:
+		return strconv.ParseBool(raw)
+	case DateField:
+		return parseFilterDate(raw)
+	case ObjectIdField:
+		return primitive.ObjectIDFromHex(raw)
+	default:
+		return raw, nil
+	}
+}
+
+// parseFilterDate : Accept full RFC3339 timestamps or plain dates
+func parseFilterDate(raw string) (primitive.DateTime, error) {
+	for _, layout := range []string{time.RFC3339, "2006-01-02"} {
+		if parsed, err := time.Parse(layout, raw); err == nil {
+			return primitive.NewDateTimeFromTime(parsed), nil
+		}
+	}
+
+	return 0, fmt.Errorf("expected RFC3339 or YYYY-MM-DD date, got '%s'", raw)
+}
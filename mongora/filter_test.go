@@ -0,0 +1,68 @@
+package mongora
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestParseFilter(t *testing.T) {
+	fields := FilterFields{
+		"price":      FloatField,
+		"status":     StringField,
+		"plays":      IntField,
+		"published":  BoolField,
+		"created_at": DateField,
+		"artist_id":  ObjectIdField,
+	}
+
+	tests := []struct {
+		name    string
+		query   string
+		want    string
+		wantErr bool
+	}{
+		{name: "bare key is equality", query: "status=active", want: `{"status":"active"}`},
+		{name: "range on one field", query: "price[gte]=10&price[lt]=20.5", want: `{"price":{"$gte":{"$numberDouble":"10.0"},"$lt":{"$numberDouble":"20.5"}}}`},
+		{name: "list operator", query: "status[in]=a, b,", want: `{"status":{"$in":["a","b"]}}`},
+		{name: "exists", query: "plays[exists]=true", want: `{"plays":{"$exists":true}}`},
+		{name: "typed values", query: "plays=3&published=false", want: `{"plays":{"$numberLong":"3"},"published":false}`},
+		{name: "date", query: "created_at[gte]=2024-01-02", want: `{"created_at":{"$gte":{"$date":{"$numberLong":"1704153600000"}}}}`},
+		{
+			name:  "params outside the whitelist are ignored",
+			query: "status=active&page_index=2&fields=title&secret[ne]=1",
+			want:  `{"status":"active"}`,
+		},
+		{
+			name:  "malformed keys outside the whitelist are ignored",
+			query: "foo[=1&utm[source=ads&[x]=1&status=active",
+			want:  `{"status":"active"}`,
+		},
+		{name: "malformed whitelisted key", query: "price[gte=10", wantErr: true},
+		{name: "unknown operator", query: "price[regex]=1", wantErr: true},
+		{name: "wrong type", query: "plays=many", wantErr: true},
+		{name: "bad date", query: "created_at=yesterday", wantErr: true},
+		{name: "bad object id", query: "artist_id=123", wantErr: true},
+		{name: "empty query", query: "", want: `{}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, err := url.ParseQuery(tt.query)
+			if err != nil {
+				t.Fatalf("parsing query: %v", err)
+			}
+
+			filter, err := ParseFilter(query, fields)
+			if tt.wantErr {
+				assertValidationError(t, err)
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := extJSON(t, filter); got != tt.want {
+				t.Errorf("filter = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,25 @@
+package mongora
+
+import (
+	"errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"testing"
+)
+
+func assertValidationError(t *testing.T, err error) {
+	t.Helper()
+	var validationError *ValidationError
+	if !errors.As(err, &validationError) {
+		t.Fatalf("expected a ValidationError, got %v", err)
+	}
+}
+
+// extJSON : Canonical extended JSON, so expected documents also pin the value types
+func extJSON(t *testing.T, document interface{}) string {
+	t.Helper()
+	data, err := bson.MarshalExtJSON(document, true, false)
+	if err != nil {
+		t.Fatalf("marshalling %v: %v", document, err)
+	}
+	return string(data)
+}
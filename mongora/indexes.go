@@ -0,0 +1,492 @@
+package mongora
+
+import (
+	"context"
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// IndexSpec : Declarative index definition, keys are ordered so compound indexes come out as declared
+type IndexSpec struct {
+	// Name defaults to MongoDB's generated name, e.g. "title_1_created_at_-1"
+	Name string
+	Keys bson.D
+
+	Unique             bool
+	Sparse             bool
+	PartialFilter      bson.D
+	ExpireAfterSeconds *int32
+	Collation          *options.Collation
+
+	// Text index options, only used when a key has the value "text"
+	Weights          map[string]int32
+	DefaultLanguage  string
+	LanguageOverride string
+}
+
+// IndexAction : What EnsureIndexes does, or would do in a dry run, with an index
+type IndexAction string
+
+const (
+	IndexUnchanged IndexAction = "unchanged"
+	IndexCreate    IndexAction = "create"
+	IndexModify    IndexAction = "modify"
+	IndexRebuild   IndexAction = "rebuild"
+	IndexDrop      IndexAction = "drop"
+)
+
+// IndexChange : One line of the EnsureIndexes report
+type IndexChange struct {
+	Name   string      `json:"name"`
+	Action IndexAction `json:"action"`
+	Reason string      `json:"reason,omitempty"`
+}
+
+// IndexReport : Every index EnsureIndexes looked at and what happened to it
+type IndexReport struct {
+	DryRun  bool          `json:"dry_run"`
+	Changes []IndexChange `json:"changes"`
+}
+
+// EnsureIndexOptions : DryRun only reports the plan, DropUnknown also drops indexes missing from the specs
+type EnsureIndexOptions struct {
+	DryRun      bool
+	DropUnknown bool
+}
+
+// indexConcurrency : Maximum number of index builds CreateSingleIndexes runs at once
+var indexConcurrency = 4
+
+// SetIndexConcurrency : Change how many indexes are built in parallel, values below 1 are ignored
+func SetIndexConcurrency(concurrency int) {
+	if concurrency > 0 {
+		indexConcurrency = concurrency
+	}
+}
+
+// GetIndexConcurrency : Current number of indexes built in parallel
+func GetIndexConcurrency() int {
+	return indexConcurrency
+}
+
+// existingIndex : Index definition as returned by listIndexes
+type existingIndex struct {
+	Name                    string      `bson:"name"`
+	Key                     bson.D      `bson:"key"`
+	Unique                  bool        `bson:"unique"`
+	Sparse                  bool        `bson:"sparse"`
+	PartialFilterExpression bson.M      `bson:"partialFilterExpression"`
+	ExpireAfterSeconds      interface{} `bson:"expireAfterSeconds"`
+	Collation               bson.M      `bson:"collation"`
+	Weights                 bson.M      `bson:"weights"`
+	DefaultLanguage         string      `bson:"default_language"`
+	LanguageOverride        string      `bson:"language_override"`
+}
+
+// EnsureIndexes : Bring the collection's indexes in line with the specs, touching only what differs.
+// A changed TTL is modified in place with collMod, any other difference drops and recreates that index.
+// An index with the spec's keys but another name is kept under its current name.
+func EnsureIndexes(ctx context.Context, collection *mongo.Collection, specs []IndexSpec, opts ...EnsureIndexOptions) (*IndexReport, error) {
+	var ensureOpts EnsureIndexOptions
+	if len(opts) > 0 {
+		ensureOpts = opts[0]
+	}
+
+	ctx, cancel := withTimeout(ctx, collection)
+	defer cancel()
+
+	existing, err := listIndexes(ctx, collection)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &IndexReport{DryRun: ensureOpts.DryRun}
+	for _, planned := range planIndexChanges(specs, existing, ensureOpts.DropUnknown) {
+		if !ensureOpts.DryRun {
+			if err := applyIndexChange(ctx, collection, planned.spec, planned.change); err != nil {
+				return report, fmt.Errorf("mongora: %s index %s on %s: %w", planned.change.Action, planned.change.Name, collectionKey(collection), err)
+			}
+		}
+		report.Changes = append(report.Changes, planned.change)
+	}
+
+	return report, nil
+}
+
+// plannedIndexChange : A change and the spec it applies, drops have no spec
+type plannedIndexChange struct {
+	change IndexChange
+	spec   IndexSpec
+}
+
+// planIndexChanges : Decide what EnsureIndexes has to do without touching the server.
+// A spec whose name is not on the server adopts an index with the same keys, so indexes created under
+// another name, e.g. by CreateIndexWithFields before specs existed, are not built a second time.
+func planIndexChanges(specs []IndexSpec, existing map[string]existingIndex, dropUnknown bool) []plannedIndexChange {
+	names := make([]string, 0, len(existing))
+	for name := range existing {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	claimed := map[string]bool{}
+	for _, spec := range specs {
+		claimed[spec.indexName()] = true
+	}
+
+	var planned []plannedIndexChange
+	for _, spec := range specs {
+		name := spec.indexName()
+		change := IndexChange{Name: name, Action: IndexCreate}
+
+		current, exists := existing[name]
+		if !exists {
+			for _, existingName := range names {
+				if !claimed[existingName] && sameIndexKeys(spec.serverKeys(), existing[existingName].Key) {
+					current, exists = existing[existingName], true
+					claimed[existingName] = true
+					break
+				}
+			}
+		}
+
+		if exists {
+			change.Name = current.Name
+			change.Action, change.Reason = diffIndex(spec, current)
+			if current.Name != name && change.Reason == "" {
+				change.Reason = "declared as " + name
+			} else if current.Name != name {
+				change.Reason += ", declared as " + name
+			}
+		}
+		planned = append(planned, plannedIndexChange{change: change, spec: spec})
+	}
+
+	if dropUnknown {
+		for _, name := range names {
+			if claimed[name] || name == "_id_" {
+				continue
+			}
+			planned = append(planned, plannedIndexChange{change: IndexChange{Name: name, Action: IndexDrop, Reason: "not declared"}})
+		}
+	}
+	return planned
+}
+
+func applyIndexChange(ctx context.Context, collection *mongo.Collection, spec IndexSpec, change IndexChange) error {
+	switch change.Action {
+	case IndexCreate:
+		_, err := collection.Indexes().CreateOne(ctx, spec.model())
+		return translateError(err)
+
+	case IndexModify:
+		command := bson.D{
+			{Key: "collMod", Value: collection.Name()},
+			{Key: "index", Value: bson.D{
+				{Key: "name", Value: change.Name},
+				{Key: "expireAfterSeconds", Value: *spec.ExpireAfterSeconds},
+			}},
+		}
+		return translateError(collection.Database().RunCommand(ctx, command).Err())
+
+	case IndexRebuild:
+		if _, err := collection.Indexes().DropOne(ctx, change.Name); err != nil {
+			return translateError(err)
+		}
+		_, err := collection.Indexes().CreateOne(ctx, spec.model())
+		return translateError(err)
+
+	case IndexDrop:
+		_, err := collection.Indexes().DropOne(ctx, change.Name)
+		return translateError(err)
+	}
+
+	return nil
+}
+
+func listIndexes(ctx context.Context, collection *mongo.Collection) (map[string]existingIndex, error) {
+	cursor, err := collection.Indexes().List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("mongora: listing indexes on %s: %w", collectionKey(collection), translateError(err))
+	}
+	defer cursor.Close(ctx)
+
+	var indexes []existingIndex
+	if err := cursor.All(ctx, &indexes); err != nil {
+		return nil, fmt.Errorf("mongora: listing indexes on %s: %w", collectionKey(collection), translateError(err))
+	}
+
+	existing := make(map[string]existingIndex, len(indexes))
+	for _, index := range indexes {
+		existing[index.Name] = index
+	}
+	return existing, nil
+}
+
+// diffIndex : Compare a spec with the index on the server and decide what has to change
+func diffIndex(spec IndexSpec, current existingIndex) (IndexAction, string) {
+	if !sameIndexKeys(spec.serverKeys(), current.Key) {
+		return IndexRebuild, "keys changed"
+	}
+	if spec.Unique != current.Unique {
+		return IndexRebuild, "unique changed"
+	}
+	if spec.Sparse != current.Sparse {
+		return IndexRebuild, "sparse changed"
+	}
+	if !sameIndexValue(spec.PartialFilter, current.PartialFilterExpression) {
+		return IndexRebuild, "partial filter changed"
+	}
+	if !sameCollation(spec.Collation, current.Collation) {
+		return IndexRebuild, "collation changed"
+	}
+
+	if spec.isText() {
+		if !sameIndexValue(spec.textWeights(), current.Weights) {
+			return IndexRebuild, "text weights changed"
+		}
+		if spec.defaultLanguage() != current.DefaultLanguage {
+			return IndexRebuild, "default language changed"
+		}
+		if spec.languageOverride() != current.LanguageOverride {
+			return IndexRebuild, "language override changed"
+		}
+	}
+
+	// TTL is the one option that can change without a rebuild
+	hasTTL := current.ExpireAfterSeconds != nil
+	switch {
+	case spec.ExpireAfterSeconds == nil && hasTTL:
+		return IndexRebuild, "ttl removed"
+	case spec.ExpireAfterSeconds != nil && !hasTTL:
+		return IndexRebuild, "ttl added"
+	case spec.ExpireAfterSeconds != nil && !sameIndexValue(*spec.ExpireAfterSeconds, current.ExpireAfterSeconds):
+		return IndexModify, "ttl changed"
+	}
+
+	return IndexUnchanged, ""
+}
+
+// indexName : MongoDB's default naming, field_value joined by underscores
+func (spec IndexSpec) indexName() string {
+	if spec.Name != "" {
+		return spec.Name
+	}
+
+	parts := make([]string, 0, len(spec.Keys))
+	for _, key := range spec.Keys {
+		parts = append(parts, fmt.Sprintf("%s_%v", key.Key, key.Value))
+	}
+	return strings.Join(parts, "_")
+}
+
+func (spec IndexSpec) isText() bool {
+	for _, key := range spec.Keys {
+		if key.Value == "text" {
+			return true
+		}
+	}
+	return false
+}
+
+func (spec IndexSpec) defaultLanguage() string {
+	if spec.DefaultLanguage == "" {
+		return "english"
+	}
+	return spec.DefaultLanguage
+}
+
+func (spec IndexSpec) languageOverride() string {
+	if spec.LanguageOverride == "" {
+		return "language"
+	}
+	return spec.LanguageOverride
+}
+
+// textWeights : Every text field with its weight, fields without an explicit weight count as 1
+func (spec IndexSpec) textWeights() bson.M {
+	weights := bson.M{}
+	for _, key := range spec.Keys {
+		if key.Value == "text" {
+			weights[key.Key] = int32(1)
+		}
+	}
+	for field, weight := range spec.Weights {
+		weights[field] = weight
+	}
+	return weights
+}
+
+// serverKeys : Text fields are stored by the server as {_fts: "text", _ftsx: 1} in place of the first text key
+func (spec IndexSpec) serverKeys() bson.D {
+	if !spec.isText() {
+		return spec.Keys
+	}
+
+	keys := bson.D{}
+	textAdded := false
+	for _, key := range spec.Keys {
+		if key.Value != "text" {
+			keys = append(keys, key)
+			continue
+		}
+		if !textAdded {
+			keys = append(keys, bson.E{Key: "_fts", Value: "text"}, bson.E{Key: "_ftsx", Value: 1})
+			textAdded = true
+		}
+	}
+	return keys
+}
+
+func (spec IndexSpec) model() mongo.IndexModel {
+	indexOpts := options.Index().SetName(spec.indexName())
+	if spec.Unique {
+		indexOpts.SetUnique(true)
+	}
+	if spec.Sparse {
+		indexOpts.SetSparse(true)
+	}
+	if len(spec.PartialFilter) > 0 {
+		indexOpts.SetPartialFilterExpression(spec.PartialFilter)
+	}
+	if spec.ExpireAfterSeconds != nil {
+		indexOpts.SetExpireAfterSeconds(*spec.ExpireAfterSeconds)
+	}
+	if spec.Collation != nil {
+		indexOpts.SetCollation(spec.Collation)
+	}
+	if spec.isText() {
+		if len(spec.Weights) > 0 {
+			indexOpts.SetWeights(spec.textWeights())
+		}
+		indexOpts.SetDefaultLanguage(spec.defaultLanguage())
+		indexOpts.SetLanguageOverride(spec.languageOverride())
+	}
+
+	return mongo.IndexModel{Keys: spec.Keys, Options: indexOpts}
+}
+
+// sameIndexKeys : Key order matters, values are compared numerically so 1 and int64(1) match
+func sameIndexKeys(expected bson.D, actual bson.D) bool {
+	if len(expected) != len(actual) {
+		return false
+	}
+
+	for i := range expected {
+		if expected[i].Key != actual[i].Key || !sameIndexValue(expected[i].Value, actual[i].Value) {
+			return false
+		}
+	}
+	return true
+}
+
+// sameCollation : Only the fields set in the spec are compared, the server fills in the rest with defaults
+func sameCollation(expected *options.Collation, actual bson.M) bool {
+	if expected == nil {
+		return len(actual) == 0
+	}
+	if len(actual) == 0 {
+		return false
+	}
+
+	for field, value := range collationFields(expected) {
+		if !sameIndexValue(value, actual[field]) {
+			return false
+		}
+	}
+	return true
+}
+
+// collationFields : The set collation fields under the camelCase names the server reports,
+// marshalling options.Collation directly would give lowercase keys
+func collationFields(collation *options.Collation) bson.M {
+	fields := bson.M{}
+	if collation.Locale != "" {
+		fields["locale"] = collation.Locale
+	}
+	if collation.CaseLevel {
+		fields["caseLevel"] = true
+	}
+	if collation.CaseFirst != "" {
+		fields["caseFirst"] = collation.CaseFirst
+	}
+	if collation.Strength != 0 {
+		fields["strength"] = collation.Strength
+	}
+	if collation.NumericOrdering {
+		fields["numericOrdering"] = true
+	}
+	if collation.Alternate != "" {
+		fields["alternate"] = collation.Alternate
+	}
+	if collation.MaxVariable != "" {
+		fields["maxVariable"] = collation.MaxVariable
+	}
+	if collation.Normalization {
+		fields["normalization"] = true
+	}
+	if collation.Backwards {
+		fields["backwards"] = true
+	}
+	return fields
+}
+
+// sameIndexValue : Deep comparison that ignores map ordering and numeric types
+func sameIndexValue(expected interface{}, actual interface{}) bool {
+	return reflect.DeepEqual(normalizeIndexValue(expected), normalizeIndexValue(actual))
+}
+
+func normalizeIndexValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case int:
+		return float64(v)
+	case int32:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case float32:
+		return float64(v)
+	case float64:
+		return v
+	case bson.D:
+		if len(v) == 0 {
+			return nil
+		}
+		normalized := map[string]interface{}{}
+		for _, elem := range v {
+			normalized[elem.Key] = normalizeIndexValue(elem.Value)
+		}
+		return normalized
+	case bson.M:
+		if len(v) == 0 {
+			return nil
+		}
+		normalized := map[string]interface{}{}
+		for key, elem := range v {
+			normalized[key] = normalizeIndexValue(elem)
+		}
+		return normalized
+	case bson.A:
+		normalized := make([]interface{}, len(v))
+		for i, elem := range v {
+			normalized[i] = normalizeIndexValue(elem)
+		}
+		return normalized
+	case []interface{}:
+		return normalizeIndexValue(bson.A(v))
+	case map[string]interface{}:
+		return normalizeIndexValue(bson.M(v))
+	case primitive.DateTime:
+		return v.Time().UTC()
+	default:
+		return v
+	}
+}
@@ -0,0 +1,134 @@
+package mongora
+
+import (
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"reflect"
+	"testing"
+)
+
+func TestPlanIndexChanges(t *testing.T) {
+	ttl := int32(3600)
+	shorterTTL := int32(60)
+
+	tests := []struct {
+		name        string
+		specs       []IndexSpec
+		existing    []existingIndex
+		dropUnknown bool
+		want        []IndexChange
+	}{
+		{
+			name:  "missing index is created",
+			specs: []IndexSpec{{Keys: bson.D{{Key: "title", Value: 1}}}},
+			want:  []IndexChange{{Name: "title_1", Action: IndexCreate}},
+		},
+		{
+			name:     "same name and definition",
+			specs:    []IndexSpec{{Keys: bson.D{{Key: "title", Value: 1}}}},
+			existing: []existingIndex{{Name: "title_1", Key: bson.D{{Key: "title", Value: int32(1)}}}},
+			want:     []IndexChange{{Name: "title_1", Action: IndexUnchanged}},
+		},
+		{
+			name:  "index created under its field name is adopted instead of duplicated",
+			specs: []IndexSpec{{Keys: bson.D{{Key: "title", Value: 1}}}},
+			existing: []existingIndex{
+				{Name: "_id_", Key: bson.D{{Key: "_id", Value: int32(1)}}},
+				{Name: "title", Key: bson.D{{Key: "title", Value: int32(1)}}},
+			},
+			dropUnknown: true,
+			want:        []IndexChange{{Name: "title", Action: IndexUnchanged, Reason: "declared as title_1"}},
+		},
+		{
+			name:     "adopted index with other options is rebuilt under the declared name",
+			specs:    []IndexSpec{{Keys: bson.D{{Key: "slug", Value: 1}}, Unique: true}},
+			existing: []existingIndex{{Name: "slug", Key: bson.D{{Key: "slug", Value: int32(1)}}}},
+			want:     []IndexChange{{Name: "slug", Action: IndexRebuild, Reason: "unique changed, declared as slug_1"}},
+		},
+		{
+			name:  "an index claimed by name is not adopted by another spec",
+			specs: []IndexSpec{{Name: "title", Keys: bson.D{{Key: "title", Value: 1}}}, {Keys: bson.D{{Key: "title", Value: 1}}}},
+			existing: []existingIndex{
+				{Name: "title", Key: bson.D{{Key: "title", Value: int32(1)}}},
+			},
+			want: []IndexChange{
+				{Name: "title", Action: IndexUnchanged},
+				{Name: "title_1", Action: IndexCreate},
+			},
+		},
+		{
+			name:     "ttl change is modified in place",
+			specs:    []IndexSpec{{Keys: bson.D{{Key: "expires_at", Value: 1}}, ExpireAfterSeconds: &shorterTTL}},
+			existing: []existingIndex{{Name: "expires_at_1", Key: bson.D{{Key: "expires_at", Value: int32(1)}}, ExpireAfterSeconds: ttl}},
+			want:     []IndexChange{{Name: "expires_at_1", Action: IndexModify, Reason: "ttl changed"}},
+		},
+		{
+			name:  "undeclared indexes are dropped, _id_ never",
+			specs: []IndexSpec{{Keys: bson.D{{Key: "title", Value: 1}}}},
+			existing: []existingIndex{
+				{Name: "_id_", Key: bson.D{{Key: "_id", Value: int32(1)}}},
+				{Name: "title_1", Key: bson.D{{Key: "title", Value: int32(1)}}},
+				{Name: "legacy", Key: bson.D{{Key: "legacy", Value: int32(1)}}},
+			},
+			dropUnknown: true,
+			want: []IndexChange{
+				{Name: "title_1", Action: IndexUnchanged},
+				{Name: "legacy", Action: IndexDrop, Reason: "not declared"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			existing := map[string]existingIndex{}
+			for _, index := range tt.existing {
+				existing[index.Name] = index
+			}
+
+			var got []IndexChange
+			for _, planned := range planIndexChanges(tt.specs, existing, tt.dropUnknown) {
+				got = append(got, planned.change)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("changes = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSameCollation(t *testing.T) {
+	// Collation as listIndexes reports it, with every default filled in
+	server := bson.M{
+		"locale": "en", "caseLevel": false, "caseFirst": "upper", "strength": int32(2),
+		"numericOrdering": true, "alternate": "non-ignorable", "maxVariable": "punct",
+		"normalization": false, "backwards": false, "version": "57.1",
+	}
+
+	tests := []struct {
+		name      string
+		collation *options.Collation
+		actual    bson.M
+		want      bool
+	}{
+		{name: "no collation on either side", want: true},
+		{name: "collation only on the server", actual: server, want: false},
+		{name: "collation only in the spec", collation: &options.Collation{Locale: "en"}, want: false},
+		{name: "locale only", collation: &options.Collation{Locale: "en"}, actual: server, want: true},
+		{
+			name:      "camelCase options match",
+			collation: &options.Collation{Locale: "en", Strength: 2, CaseFirst: "upper", NumericOrdering: true, MaxVariable: "punct"},
+			actual:    server,
+			want:      true,
+		},
+		{name: "strength differs", collation: &options.Collation{Locale: "en", Strength: 3}, actual: server, want: false},
+		{name: "locale differs", collation: &options.Collation{Locale: "fr"}, actual: server, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sameCollation(tt.collation, tt.actual); got != tt.want {
+				t.Errorf("sameCollation = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,256 @@
+package mongora
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"strings"
+)
+
+// KeysetPage : One page of keyset paginated results, pass Next as `after` to fetch the following page
+type KeysetPage[T any] struct {
+	Items []T    `json:"items"`
+	Next  string `json:"next,omitempty"`
+}
+
+// cursorSecret : Key used to sign continuation tokens, random per process until SetCursorSecret is called
+var cursorSecret = func() []byte {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		panic(err)
+	}
+	return secret
+}()
+
+// SetCursorSecret : Set the key continuation tokens are signed with, share it between instances behind a load balancer
+func SetCursorSecret(secret []byte) { cursorSecret = secret }
+
+// keysetCursor : Position of the last document of a page
+type keysetCursor struct {
+	Field     string      `bson:"f"`
+	Direction int         `bson:"d"`
+	Value     interface{} `bson:"v"`
+	Id        interface{} `bson:"id"`
+}
+
+// FindAfter : Keyset paginated Find ordered by a single-field `sort` or the order_by/order context keys, plus _id
+func FindAfter(ctx context.Context, collection *mongo.Collection, filter interface{}, after string) (*KeysetPage[bson.M], error) {
+	return findAfter[bson.M](ctx, collection, filter, after)
+}
+
+// FindAfter : Typed variant of FindAfter
+func (r *Repository[T]) FindAfter(ctx context.Context, filter interface{}, after string) (*KeysetPage[T], error) {
+	return findAfter[T](ctx, r.collection, filter, after)
+}
+
+func findAfter[T any](ctx context.Context, collection *mongo.Collection, filter interface{}, after string) (*KeysetPage[T], error) {
+	ctx, cancel := withTimeout(ctx, collection)
+	defer cancel()
+
+	if filter == nil {
+		filter = bson.D{}
+	}
+
+	orderBy, direction, err := keysetSortKey(ctx, GetCollectionOptions(collection).SortFields)
+	if err != nil {
+		return nil, err
+	}
+
+	// Make sure the sort key survives the projection so the next token can be built
+	opts, err := buildOptionsForQuery(ctx, collection, orderBy)
+	if err != nil {
+		return nil, err
+	}
+	_, limit := resolvePaging(ctx)
+
+	sortOrder := bson.D{}
+	if orderBy != "_id" {
+		sortOrder = append(sortOrder, bson.E{Key: orderBy, Value: direction})
+	}
+	sortOrder = append(sortOrder, bson.E{Key: "_id", Value: direction})
+
+	// Fetch one extra document to know whether another page exists
+	opts.SetSort(sortOrder).SetSkip(0).SetLimit(limit + 1)
+
+	if after != "" {
+		cursor, err := decodeCursorFor(after, orderBy, direction)
+		if err != nil {
+			return nil, err
+		}
+		filter = bson.D{{Key: "$and", Value: bson.A{filter, keysetFilter(cursor)}}}
+	}
+
+	results, err := collection.Find(ctx, scopeFilter(ctx, collection, filter), opts)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	defer results.Close(ctx)
+
+	var raws []bson.Raw
+	if err := results.All(ctx, &raws); err != nil {
+		return nil, fmt.Errorf("mongora: decoding documents: %w", translateError(err))
+	}
+
+	hasMore := int64(len(raws)) > limit
+	if hasMore {
+		raws = raws[:limit]
+	}
+
+	page := &KeysetPage[T]{Items: make([]T, 0, len(raws))}
+	for _, raw := range raws {
+		var item T
+		if err := bson.Unmarshal(raw, &item); err != nil {
+			return nil, fmt.Errorf("mongora: decoding documents: %w", err)
+		}
+		page.Items = append(page.Items, item)
+	}
+
+	if hasMore {
+		last := raws[len(raws)-1]
+		next := keysetCursor{Field: orderBy, Direction: direction}
+
+		if value, err := last.LookupErr(strings.Split(orderBy, ".")...); err == nil {
+			_ = value.Unmarshal(&next.Value)
+		}
+		if id, err := last.LookupErr("_id"); err == nil {
+			_ = id.Unmarshal(&next.Id)
+		}
+
+		page.Next, err = encodeCursor(next)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return page, nil
+}
+
+// keysetSortKey : Sort field and direction from the `sort` or order_by/order context keys, _id when unset.
+// The cursor holds a single key, so a `sort` naming more than one field besides _id is rejected.
+func keysetSortKey(ctx context.Context, allowed []string) (string, int, error) {
+	if expression := contextString(ctx, "sort"); expression != "" {
+		sortOrder, err := ParseSort(expression, allowed)
+		if err != nil {
+			return "", 0, err
+		}
+
+		// ParseSort ends with the _id tiebreaker unless _id was the only key
+		if len(sortOrder) > 1 && sortOrder[len(sortOrder)-1].Key == "_id" {
+			sortOrder = sortOrder[:len(sortOrder)-1]
+		}
+		if len(sortOrder) != 1 {
+			return "", 0, &ValidationError{Message: "Invalid sort", Fields: []FieldError{
+				{Field: "sort", Tag: "sort", Message: "keyset pagination sorts on a single field"},
+			}}
+		}
+		return sortOrder[0].Key, sortOrder[0].Value.(int), nil
+	}
+
+	orderBy := contextString(ctx, "order_by")
+	order := contextString(ctx, "order")
+
+	direction := 1
+	if order == "desc" {
+		direction = -1
+	}
+
+	if orderBy == "" {
+		orderBy = "_id"
+	}
+	if !isSortFieldAllowed(orderBy, allowed) {
+		return "", 0, &ValidationError{Message: "Invalid sort", Fields: []FieldError{
+			{Field: orderBy, Tag: "sort", Message: fmt.Sprintf("sorting on '%s' is not allowed", orderBy)},
+		}}
+	}
+	return orderBy, direction, nil
+}
+
+// keysetFilter : Documents strictly after the cursor in (sort key, _id) order
+func keysetFilter(cursor *keysetCursor) bson.D {
+	operator := "$gt"
+	if cursor.Direction < 0 {
+		operator = "$lt"
+	}
+
+	if cursor.Field == "_id" {
+		return bson.D{{Key: "_id", Value: bson.D{{Key: operator, Value: cursor.Id}}}}
+	}
+
+	sameKey := bson.D{
+		{Key: cursor.Field, Value: cursor.Value},
+		{Key: "_id", Value: bson.D{{Key: operator, Value: cursor.Id}}},
+	}
+
+	// Null and missing values sort before everything else and never match $gt/$lt, so they need their own branches
+	if cursor.Value == nil {
+		if cursor.Direction < 0 {
+			return sameKey
+		}
+		return bson.D{{Key: "$or", Value: bson.A{
+			sameKey,
+			bson.D{{Key: cursor.Field, Value: bson.D{{Key: "$ne", Value: nil}}}},
+		}}}
+	}
+
+	after := bson.A{
+		bson.D{{Key: cursor.Field, Value: bson.D{{Key: operator, Value: cursor.Value}}}},
+		sameKey,
+	}
+	if cursor.Direction < 0 {
+		after = append(after, bson.D{{Key: cursor.Field, Value: nil}})
+	}
+	return bson.D{{Key: "$or", Value: after}}
+}
+
+// encodeCursor : Serialize the cursor as extended JSON so value types survive, then sign it
+func encodeCursor(cursor keysetCursor) (string, error) {
+	payload, err := bson.MarshalExtJSON(cursor, true, false)
+	if err != nil {
+		return "", err
+	}
+
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + signCursor(encoded), nil
+}
+
+// decodeCursorFor : Decode a token that must have been issued for the same sort field and direction
+func decodeCursorFor(token string, orderBy string, direction int) (*keysetCursor, error) {
+	cursor, err := decodeCursor(token)
+	if err != nil {
+		return nil, err
+	}
+	if cursor.Field != orderBy || cursor.Direction != direction {
+		return nil, fmt.Errorf("%w: sort order changed", ErrInvalidCursor)
+	}
+	return cursor, nil
+}
+
+func decodeCursor(token string) (*keysetCursor, error) {
+	encoded, signature, found := strings.Cut(token, ".")
+	if !found || !hmac.Equal([]byte(signature), []byte(signCursor(encoded))) {
+		return nil, ErrInvalidCursor
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var cursor keysetCursor
+	if err := bson.UnmarshalExtJSON(payload, true, &cursor); err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	return &cursor, nil
+}
+
+func signCursor(encoded string) string {
+	mac := hmac.New(sha256.New, cursorSecret)
+	mac.Write([]byte(encoded))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,124 @@
+package mongora
+
+import (
+	"errors"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"strings"
+	"testing"
+)
+
+func TestKeysetFilter(t *testing.T) {
+	tests := []struct {
+		name   string
+		cursor keysetCursor
+		want   string
+	}{
+		{
+			name:   "_id only",
+			cursor: keysetCursor{Field: "_id", Direction: 1, Id: "b"},
+			want:   `{"_id":{"$gt":"b"}}`,
+		},
+		{
+			name:   "ascending",
+			cursor: keysetCursor{Field: "plays", Direction: 1, Value: "m", Id: "b"},
+			want:   `{"$or":[{"plays":{"$gt":"m"}},{"plays":"m","_id":{"$gt":"b"}}]}`,
+		},
+		{
+			name:   "descending keeps the nulls sorted after the cursor",
+			cursor: keysetCursor{Field: "plays", Direction: -1, Value: "m", Id: "b"},
+			want:   `{"$or":[{"plays":{"$lt":"m"}},{"plays":"m","_id":{"$lt":"b"}},{"plays":null}]}`,
+		},
+		{
+			name:   "ascending from a null value",
+			cursor: keysetCursor{Field: "plays", Direction: 1, Id: "b"},
+			want:   `{"$or":[{"plays":null,"_id":{"$gt":"b"}},{"plays":{"$ne":null}}]}`,
+		},
+		{
+			name:   "descending from a null value",
+			cursor: keysetCursor{Field: "plays", Direction: -1, Id: "b"},
+			want:   `{"plays":null,"_id":{"$lt":"b"}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := extJSON(t, keysetFilter(&tt.cursor)); got != tt.want {
+				t.Errorf("filter = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKeysetCursorRoundTrip(t *testing.T) {
+	id := primitive.NewObjectID()
+	cursors := []keysetCursor{
+		{Field: "_id", Direction: 1, Id: id},
+		{Field: "created_at", Direction: -1, Value: primitive.NewDateTimeFromTime(id.Timestamp()), Id: id},
+		{Field: "plays", Direction: 1, Value: int64(42), Id: id},
+		{Field: "title.en", Direction: 1, Id: id},
+	}
+
+	for _, cursor := range cursors {
+		token, err := encodeCursor(cursor)
+		if err != nil {
+			t.Fatalf("encoding %+v: %v", cursor, err)
+		}
+
+		decoded, err := decodeCursor(token)
+		if err != nil {
+			t.Fatalf("decoding %+v: %v", cursor, err)
+		}
+		if got, want := extJSON(t, decoded), extJSON(t, cursor); got != want {
+			t.Errorf("round trip = %s, want %s", got, want)
+		}
+	}
+}
+
+func TestDecodeCursorRejectsTampering(t *testing.T) {
+	token, err := encodeCursor(keysetCursor{Field: "plays", Direction: 1, Value: int64(42), Id: "b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resorted, err := encodeCursor(keysetCursor{Field: "plays", Direction: -1, Value: int64(42), Id: "b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload, signature, _ := strings.Cut(token, ".")
+	resortedPayload, _, _ := strings.Cut(resorted, ".")
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{name: "empty", token: ""},
+		{name: "no signature", token: payload},
+		{name: "payload swapped for another sort order", token: resortedPayload + "." + signature},
+		{name: "signature changed", token: payload + "." + strings.ToUpper(signature)},
+		{name: "not base64", token: "!!!." + signature},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := decodeCursor(tt.token); !errors.Is(err, ErrInvalidCursor) {
+				t.Errorf("err = %v, want ErrInvalidCursor", err)
+			}
+		})
+	}
+}
+
+func TestDecodeCursorForRejectsSortChanges(t *testing.T) {
+	token, err := encodeCursor(keysetCursor{Field: "plays", Direction: 1, Value: int64(42), Id: "b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := decodeCursorFor(token, "plays", 1); err != nil {
+		t.Fatalf("same sort order: %v", err)
+	}
+	if _, err := decodeCursorFor(token, "plays", -1); !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("reversed order: err = %v, want ErrInvalidCursor", err)
+	}
+	if _, err := decodeCursorFor(token, "created_at", 1); !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("other field: err = %v, want ErrInvalidCursor", err)
+	}
+}
@@ -2,7 +2,6 @@ package mongora
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/go-playground/validator/v10"
@@ -11,13 +10,12 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
-	"io"
-	"log"
 	"net/http"
 	"net/url"
+	"reflect"
+	"sort"
 	"strings"
 	"sync"
-	"time"
 )
 
 // BodyValidate : Create a new global validator for request body
@@ -28,31 +26,51 @@ var BodyValidate *validator.Validate = validator.New()
 // ////////////////
 
 func InsertOne(collection *mongo.Collection, reqBody interface{}) (primitive.ObjectID, error) {
-	// Set a context with a timeout for the insert operation
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	return InsertOneWithContext(context.Background(), collection, reqBody)
+}
+
+// InsertOneWithContext : Insert a record, bounded by the caller's context and the collection timeout.
+// A document with a non-ObjectID _id is still inserted but reported as an error, Repository.Insert returns any id type.
+func InsertOneWithContext(ctx context.Context, collection *mongo.Collection, reqBody interface{}) (primitive.ObjectID, error) {
+	// Apply the timeout policy when the caller has no deadline of its own
+	ctx, cancel := withTimeout(ctx, collection)
 	defer cancel()
 
-	// Insert the record
-	recordId, err := collection.InsertOne(ctx, reqBody)
+	// Stamp created_at/updated_at on collections configured for timestamps
+	document, err := stampInsert(ctx, collection, reqBody)
 	if err != nil {
 		return primitive.NilObjectID, err
 	}
 
+	// Insert the record
+	recordId, err := collection.InsertOne(ctx, document)
+	if err != nil {
+		return primitive.NilObjectID, translateError(err)
+	}
+
 	//jsonData, err := json.Marshal(recordData)
 	//if err != nil {
 	//	return nil, fmt.Sprintf("Error converting BSON to JSON: %v", err)
 	//}
 
-	return recordId.InsertedID.(primitive.ObjectID), nil
+	objectId, ok := recordId.InsertedID.(primitive.ObjectID)
+	if !ok {
+		return primitive.NilObjectID, fmt.Errorf("mongora: inserted _id %v is a %T, not an ObjectID", recordId.InsertedID, recordId.InsertedID)
+	}
+	return objectId, nil
 }
 
 func DeleteOne(collection *mongo.Collection, filter interface{}) (bool, error) {
-	// Set a context with a timeout for the insert operation
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	return DeleteOneWithContext(context.Background(), collection, filter)
+}
+
+// DeleteOneWithContext : Delete the first matching record using the caller's context
+func DeleteOneWithContext(ctx context.Context, collection *mongo.Collection, filter interface{}) (bool, error) {
+	ctx, cancel := withTimeout(ctx, collection)
 	defer cancel()
 
-	// Insert the record
-	_, err := collection.DeleteOne(ctx, filter)
+	// Delete the record, or only mark it as deleted on soft-delete collections
+	_, err := deleteOne(ctx, collection, filter)
 	if err != nil {
 		return false, err
 	}
@@ -61,30 +79,50 @@ func DeleteOne(collection *mongo.Collection, filter interface{}) (bool, error) {
 }
 
 func FindOneAndUpdate(collection *mongo.Collection, filter interface{}, update interface{}) (bson.M, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	return FindOneAndUpdateWithContext(context.Background(), collection, filter, update)
+}
+
+// FindOneAndUpdateWithContext : Update the first matching record and return it after the update
+func FindOneAndUpdateWithContext(ctx context.Context, collection *mongo.Collection, filter interface{}, update interface{}) (bson.M, error) {
+	ctx, cancel := withTimeout(ctx, collection)
 	defer cancel()
 
+	update, err := stampUpdate(ctx, collection, update, false)
+	if err != nil {
+		return nil, err
+	}
+
 	var result bson.M
 
 	opts := options.FindOneAndUpdate().SetReturnDocument(options.After) // Return the document after update
-	err := collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&result)
+	err = collection.FindOneAndUpdate(ctx, scopeFilter(ctx, collection, filter), update, opts).Decode(&result)
 
 	if err != nil {
-		return nil, err
+		return nil, translateError(err)
 	}
 
 	return result, nil
 }
 
 func FindOneAndDelete(collection *mongo.Collection, filter interface{}) (bson.M, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	return FindOneAndDeleteWithContext(context.Background(), collection, filter)
+}
+
+// FindOneAndDeleteWithContext : Delete the first matching record using the caller's context
+func FindOneAndDeleteWithContext(ctx context.Context, collection *mongo.Collection, filter interface{}) (bson.M, error) {
+	ctx, cancel := withTimeout(ctx, collection)
 	defer cancel()
 
 	var result bson.M
-	err := collection.FindOneAndDelete(ctx, filter).Decode(&result)
+	var err error
+	if GetCollectionOptions(collection).SoftDelete {
+		err = collection.FindOneAndUpdate(ctx, scopeFilter(ctx, collection, filter), softDeleteUpdate(ctx)).Decode(&result)
+	} else {
+		err = collection.FindOneAndDelete(ctx, filter).Decode(&result)
+	}
 
 	if err != nil {
-		return nil, err
+		return nil, translateError(err)
 		//if errors.Is(err, mongo.ErrNoDocuments) {
 		//	return nil, "No document found"
 		//} else {
@@ -100,41 +138,30 @@ func FindOneAndDelete(collection *mongo.Collection, filter interface{}) (bson.M,
 }
 
 func FindByIdOrSlug(collection *mongo.Collection, id string) (bson.M, error) {
-	if IsValidObjectID(id) {
-		return FindById(collection, id)
-	}
+	return FindByIdOrSlugWithContext(context.Background(), collection, id)
+}
 
-	filter := bson.D{{"slug", id}}
-	document, err := FindOne(collection, filter)
-	return document, err
+// FindByIdOrSlugWithContext : Find by ObjectID or slug using the caller's context
+func FindByIdOrSlugWithContext(ctx context.Context, collection *mongo.Collection, id string) (bson.M, error) {
+	return derefDocument(findByIdOrSlug[bson.M](ctx, collection, id))
 }
 
 func FindById(collection *mongo.Collection, id string) (bson.M, error) {
-	if !IsValidObjectID(id) {
-		return nil, errors.New("Invalid Object ID")
-	}
-
-	//Find By ID
-	objectID, _ := primitive.ObjectIDFromHex(id)
-	filter := bson.M{"_id": objectID}
-
-	var result bson.M
-	err := collection.FindOne(context.TODO(), filter).Decode(&result)
-	if err != nil {
-		return nil, err
-	}
+	return FindByIdWithContext(context.Background(), collection, id)
+}
 
-	return result, nil
+// FindByIdWithContext : Find by ObjectID using the caller's context
+func FindByIdWithContext(ctx context.Context, collection *mongo.Collection, id string) (bson.M, error) {
+	return derefDocument(findById[bson.M](ctx, collection, id))
 }
 
 func FindOne(collection *mongo.Collection, filter interface{}) (bson.M, error) {
-	var result bson.M
-	err := collection.FindOne(context.TODO(), filter).Decode(&result)
-	if err != nil {
-		return nil, err
-	}
+	return FindOneWithContext(context.Background(), collection, filter)
+}
 
-	return result, nil
+// FindOneWithContext : Find the first matching record using the caller's context
+func FindOneWithContext(ctx context.Context, collection *mongo.Collection, filter interface{}) (bson.M, error) {
+	return derefDocument(findOne[bson.M](ctx, collection, filter))
 }
 
 // ProcessFindQuery : Find Query processing
@@ -144,32 +171,7 @@ func Find(ctx context.Context, collection *mongo.Collection, filter interface{})
 }
 
 func FindWithAddonFields(ctx context.Context, collection *mongo.Collection, filter interface{}, addonFields string) ([]bson.M, error) {
-	opts := buildOptionsForQuery(ctx, addonFields)
-	cursor, err := collection.Find(ctx, filter, opts)
-
-	if err != nil {
-		return nil, err
-	}
-
-	//This function gets call after everything is completed, in this case, it gets called once the query is completed
-	defer func(cursor *mongo.Cursor, ctx context.Context) {
-		//log.Println("Closing cursor")
-		err := cursor.Close(ctx)
-		if err != nil {
-			_ = fmt.Sprintf("%v", err)
-		}
-	}(cursor, ctx)
-
-	// Create a slice to hold the results
-	var results []bson.M
-
-	// Iterate over the cursor and decode each document to parse as JSON data later
-	if err := cursor.All(ctx, &results); err != nil {
-		return nil, errors.New(fmt.Sprintf("Error decoding documents: %v", err))
-	}
-
-	//log.Println("Query completed")
-	return results, nil
+	return findMany[bson.M](ctx, collection, filter, addonFields)
 }
 
 // /////////////////
@@ -236,11 +238,11 @@ func ObjectIdToString(objectId primitive.ObjectID) string {
 }
 
 func GetSortString(ctx context.Context) string {
-	orderBy := goNest.GetCtxStringValue(ctx, "order_by")
-	order := goNest.GetCtxStringValue(ctx, "order")
-	pageIndex := goNest.GetCtxStringValue(ctx, "page_index")
-	countPerPage := goNest.GetCtxStringValue(ctx, "count_per_page")
-	projection := goNest.GetCtxStringValue(ctx, "projection")
+	orderBy := contextString(ctx, "order_by")
+	order := contextString(ctx, "order")
+	pageIndex := contextString(ctx, "page_index")
+	countPerPage := contextString(ctx, "count_per_page")
+	projection := contextString(ctx, "projection")
 	projection = strings.ReplaceAll(projection, " ", "")
 
 	return fmt.Sprintf("order_by=%s&order=%s&page_index=%s&count_per_page=%s&fields=%s", orderBy, order, pageIndex, countPerPage, projection)
@@ -264,27 +266,25 @@ func AppendBoolFilter(queryParams url.Values, filter bson.D, key string) (bson.D
 	return filter, val
 }
 
+// ValidateRequestBody : Decode the JSON body into model, which must be a pointer to a struct, and validate it.
+// Kept lenient for existing callers: unknown fields and any Content-Type are accepted. Prefer DecodeAndValidate.
 func ValidateRequestBody(req *http.Request, model any) (bool, error) {
-	body, err := io.ReadAll(req.Body)
-	if err != nil {
-		return false, errors.New("Unable to read request body")
+	if value := reflect.ValueOf(model); value.Kind() != reflect.Pointer || value.IsNil() {
+		return false, fmt.Errorf("mongora: ValidateRequestBody needs a non-nil pointer, got %T", model)
 	}
 
-	// Unmarshal JSON if the body is expected to be JSON
-	//var requestData karaoke.SongViewRequestData
-	if err := json.Unmarshal(body, &model); err != nil {
-		return false, errors.New("Request Body: Invalid JSON format")
-	}
-
-	// Validate the struct
-	if err := BodyValidate.Struct(model); err != nil {
-		return false, errors.New(fmt.Sprintf("Request Body validation error: %s", err))
+	err := decodeAndValidate(req, model, DecodeOptions{AllowUnknownFields: true, AllowAnyContentType: true})
+	if err != nil {
+		return false, err
 	}
 
 	return true, nil
 }
 
 // GenerateSearchTokens : Tokenized the given text so that it can search between spaces as well
+//
+// Deprecated: the token count grows quadratically with the word count and Myanmar text is not split at all,
+// use NewSearchTokenizer with WithSearchTokens instead.
 func GenerateSearchTokens(text string) []string {
 	// Split the text into words
 	words := strings.Fields(text)
@@ -304,95 +304,128 @@ func GenerateSearchTokens(text string) []string {
 // /////////////////////////////////
 // // Private Utility Functions ////
 // /////////////////////////////////
-func buildOptionsForQuery(ctx context.Context, addonFields string) *options.FindOptions {
-	opts := options.Find()
+// findOne : Decode a single document matching the filter into T
+func findOne[T any](ctx context.Context, collection *mongo.Collection, filter interface{}) (*T, error) {
+	ctx, cancel := withTimeout(ctx, collection)
+	defer cancel()
 
-	// Define the projection
-	fields := goNest.GetCtxStringValue(ctx, "fields")
-	if addonFields != "" {
-		if !(strings.Contains(fields, addonFields)) {
-			fields = fields + "," + addonFields
-		}
+	var result T
+	err := collection.FindOne(ctx, scopeFilter(ctx, collection, filter)).Decode(&result)
+	if err != nil {
+		return nil, translateError(err)
 	}
 
-	if fields != "" {
-		projection, _ := parseProjection(fields)
+	return &result, nil
+}
 
-		//TODO: Add multiple sort options for better searching and sorting
-		opts = opts.SetProjection(projection)
+// findById : Decode the document with the given hex ObjectID into T
+func findById[T any](ctx context.Context, collection *mongo.Collection, id string) (*T, error) {
+	if !IsValidObjectID(id) {
+		return nil, ErrInvalidID
 	}
 
-	//Check for other fields
-	orderBy := goNest.GetCtxStringValue(ctx, "order_by")
-	order := goNest.GetCtxStringValue(ctx, "order")
+	//Find By ID
+	objectID, _ := primitive.ObjectIDFromHex(id)
+	filter := bson.M{"_id": objectID}
 
-	sortOrder := bson.D{}
-	if orderBy != "" && order != "" {
-		orderValue := 1
-		if order == "desc" {
-			orderValue = -1
-		}
-		sortOrder = append(sortOrder, bson.E{Key: orderBy, Value: orderValue})
+	return findOne[T](ctx, collection, filter)
+}
 
-		opts.SetSort(sortOrder)
+// findByIdOrSlug : Look up by ObjectID when the value is a valid hex ID, otherwise by slug
+func findByIdOrSlug[T any](ctx context.Context, collection *mongo.Collection, id string) (*T, error) {
+	if IsValidObjectID(id) {
+		return findById[T](ctx, collection, id)
 	}
 
-	skip := int64(goNest.GetCtxIntValue(ctx, "skip"))
-	limit := int64(goNest.GetCtxIntValue(ctx, "limit"))
-	if skip >= 0 && limit > 0 {
-		opts = opts.SetSkip(skip).SetLimit(limit)
-	} else {
-		skip = 0
-		limit = 10
-	}
+	filter := bson.D{{Key: "slug", Value: id}}
+	return findOne[T](ctx, collection, filter)
+}
 
-	//log.Println(projection)
-	//log.Println(sortOrder)
-	//log.Println(skip)
-	//log.Println(limit)
+// findMany : Run a Find with the query options stored in the context and decode every document into T
+func findMany[T any](ctx context.Context, collection *mongo.Collection, filter interface{}, addonFields string) ([]T, error) {
+	ctx, cancel := withTimeout(ctx, collection)
+	defer cancel()
+
+	opts, err := buildOptionsForQuery(ctx, collection, addonFields)
+	if err != nil {
+		return nil, err
+	}
 
-	return opts
+	return runFind[T](ctx, collection, filter, opts)
 }
 
-func parseProjection(fields string) (bson.D, error) {
-	if fields == "" {
-		return bson.D{}, errors.New("fields string cannot be empty")
+// runFind : Run a scoped Find with prepared options and decode every document into T
+func runFind[T any](ctx context.Context, collection *mongo.Collection, filter interface{}, opts *options.FindOptions) ([]T, error) {
+	cursor, err := collection.Find(ctx, scopeFilter(ctx, collection, filter), opts)
+
+	if err != nil {
+		return nil, translateError(err)
 	}
 
-	//Remove commas from the start and end character of the string
-	fields = strings.Trim(fields, ",")
+	//This function gets call after everything is completed, in this case, it gets called once the query is completed
+	defer func(cursor *mongo.Cursor, ctx context.Context) {
+		//log.Println("Closing cursor")
+		err := cursor.Close(ctx)
+		if err != nil {
+			_ = fmt.Sprintf("%v", err)
+		}
+	}(cursor, ctx)
+
+	// Create a slice to hold the results
+	var results []T
 
-	parts := strings.Split(fields, ",")
-	var projectionType int
-	projection := bson.D{}
+	// Iterate over the cursor and decode each document to parse as JSON data later
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, fmt.Errorf("mongora: decoding documents: %w", translateError(err))
+	}
+
+	//log.Println("Query completed")
+	return results, nil
+}
 
-	for i, part := range parts {
-		part = strings.TrimSpace(part)
+// derefDocument : Unwrap a *bson.M result so the untyped helpers keep returning bson.M
+func derefDocument(document *bson.M, err error) (bson.M, error) {
+	if err != nil {
+		return nil, err
+	}
 
-		if part != "" {
-			// Determine projection type based on prefix
-			if strings.HasPrefix(part, "-") {
-				if i == 0 {
-					projectionType = 0
-				} else if projectionType != 0 {
-					return nil, errors.New("mix of inclusion and exclusion fields is not allowed")
-				}
-				part = strings.TrimPrefix(part, "-")
-			} else {
-				if i == 0 {
-					projectionType = 1
-				} else if projectionType != 1 {
-					return nil, errors.New("mix of inclusion and exclusion fields is not allowed")
-				}
-			}
+	return *document, nil
+}
 
-			// Append to projection
-			projection = append(projection, bson.E{Key: part, Value: projectionType})
+func buildOptionsForQuery(ctx context.Context, collection *mongo.Collection, addonFields string) (*options.FindOptions, error) {
+	opts := options.Find()
+
+	// Define the projection
+	collectionOpts := GetCollectionOptions(collection)
+	fields := mergeProjectionFields(contextString(ctx, "fields"), addonFields)
+	if fields != "" {
+		projection, err := BuildProjection(fields, collectionOpts.ProjectionFields)
+		if err != nil {
+			return nil, err
 		}
+		opts = opts.SetProjection(projection)
+	}
 
+	//Check for sort fields against the collection allowlist
+	sortOrder, err := sortFromContext(ctx, collectionOpts.SortFields)
+	if err != nil {
+		return nil, err
+	}
+	if len(sortOrder) > 0 {
+		opts.SetSort(sortOrder)
 	}
 
-	return projection, nil
+	// Without a page size in the context every match is returned, FindPage applies the default page size
+	if skip, limit, ok := contextPaging(ctx); ok {
+		opts = opts.SetSkip(skip).SetLimit(limit)
+	}
+
+	//log.Println(projection)
+	//log.Println(sortOrder)
+	//log.Println(skip)
+	//log.Println(limit)
+
+	return opts, nil
 }
 
 // /////////////////
@@ -401,12 +434,17 @@ func parseProjection(fields string) (bson.D, error) {
 
 // DropIndex : Drop index for the collection
 func DropIndex(collection *mongo.Collection, indexName string) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	return DropIndexWithContext(context.Background(), collection, indexName)
+}
+
+// DropIndexWithContext : Drop index for the collection using the caller's context
+func DropIndexWithContext(ctx context.Context, collection *mongo.Collection, indexName string) error {
+	ctx, cancel := withTimeout(ctx, collection)
 	defer cancel()
 
-	exists, err := IndexExists(collection, indexName)
+	exists, err := IndexExistsWithContext(ctx, collection, indexName)
 	if err != nil {
-		log.Fatalf("Error checking index existence: %v", err)
+		return fmt.Errorf("mongora: checking index %s on %s: %w", indexName, collectionKey(collection), err)
 	}
 
 	//Only drop the index if the name exist
@@ -414,7 +452,7 @@ func DropIndex(collection *mongo.Collection, indexName string) error {
 		// Drop the existing index
 		_, err := collection.Indexes().DropOne(ctx, indexName)
 		if err != nil {
-			return err
+			return fmt.Errorf("mongora: dropping index %s on %s: %w", indexName, collectionKey(collection), translateError(err))
 		}
 	} else {
 		//No index exist
@@ -425,13 +463,18 @@ func DropIndex(collection *mongo.Collection, indexName string) error {
 }
 
 func IndexExists(collection *mongo.Collection, indexName string) (bool, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	return IndexExistsWithContext(context.Background(), collection, indexName)
+}
+
+// IndexExistsWithContext : Check whether the named index exists using the caller's context
+func IndexExistsWithContext(ctx context.Context, collection *mongo.Collection, indexName string) (bool, error) {
+	ctx, cancel := withTimeout(ctx, collection)
 	defer cancel()
 
 	// Retrieve all indexes
 	cursor, err := collection.Indexes().List(ctx)
 	if err != nil {
-		return false, err
+		return false, translateError(err)
 	}
 	defer cursor.Close(ctx)
 
@@ -448,71 +491,80 @@ func IndexExists(collection *mongo.Collection, indexName string) (bool, error) {
 		}
 	}
 
-	return false, cursor.Err()
+	return false, translateError(cursor.Err())
 }
 
-// CreateSingleIndexes : Helper function to create single indexes for the collection
-func CreateSingleIndexes(collection *mongo.Collection, indexNames []string) {
-	//log.Println("Creating single indexes")
-	var wg sync.WaitGroup
-	for _, indexName := range indexNames {
-		wg.Add(1) // Increment WaitGroup counter
-		go func(indexName string) {
-			defer wg.Done() // Decrement counter when goroutine completes
-			err := CreateIndexWithFields(collection, indexName, map[string]interface{}{indexName: 1})
-			if err != nil {
-				_ = fmt.Sprintf("%v\n", err)
-			}
-		}(indexName) // Pass indexName as argument to avoid capture by reference
-	}
-	wg.Wait() // Block until all goroutines have completed
-	//log.Println("Single indexes created")
-}
-
-// CreateSingleHashIndexes : Helper function to create single hash indexes for the collection
-func CreateSingleHashIndexes(collection *mongo.Collection, indexNames []string) {
-	//log.Println("Creating single indexes")
+// CreateSingleIndexes : Helper function to create single indexes for the collection.
+// Every index is attempted, the returned error joins one error per failed index.
+func CreateSingleIndexes(collection *mongo.Collection, indexNames []string) error {
+	return CreateSingleIndexesWithContext(context.Background(), collection, indexNames)
+}
+
+// CreateSingleIndexesWithContext : Same as CreateSingleIndexes but bounded by the caller's context
+func CreateSingleIndexesWithContext(ctx context.Context, collection *mongo.Collection, indexNames []string) error {
+	return createSingleIndexes(ctx, collection, indexNames, 1)
+}
+
+// CreateSingleHashIndexes : Helper function to create single hash indexes for the collection.
+// Every index is attempted, the returned error joins one error per failed index.
+func CreateSingleHashIndexes(collection *mongo.Collection, indexNames []string) error {
+	return CreateSingleHashIndexesWithContext(context.Background(), collection, indexNames)
+}
+
+// CreateSingleHashIndexesWithContext : Same as CreateSingleHashIndexes but bounded by the caller's context
+func CreateSingleHashIndexesWithContext(ctx context.Context, collection *mongo.Collection, indexNames []string) error {
+	return createSingleIndexes(ctx, collection, indexNames, "hashed")
+}
+
+// createSingleIndexes : Create one index per field, at most indexConcurrency at a time
+func createSingleIndexes(ctx context.Context, collection *mongo.Collection, indexNames []string, indexType interface{}) error {
+	errs := make([]error, len(indexNames))
+	semaphore := make(chan struct{}, GetIndexConcurrency())
+
 	var wg sync.WaitGroup
-	for _, indexName := range indexNames {
-		wg.Add(1) // Increment WaitGroup counter
-		go func(indexName string) {
-			defer wg.Done() // Decrement counter when goroutine completes
-			err := CreateIndexWithFields(collection, indexName, map[string]interface{}{indexName: "hashed"})
-			if err != nil {
-				_ = fmt.Sprintf("%v\n", err)
-			}
-		}(indexName) // Pass indexName as argument to avoid capture by reference
+	for i, indexName := range indexNames {
+		wg.Add(1)
+		go func(i int, indexName string) {
+			defer wg.Done()
+
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			errs[i] = CreateIndexWithFieldsWithContext(ctx, collection, indexName, map[string]interface{}{indexName: indexType})
+		}(i, indexName)
 	}
-	wg.Wait() // Block until all goroutines have completed
-	//log.Println("Single indexes created")
+	wg.Wait()
+
+	// Joined in input order so the report is stable between runs
+	return errors.Join(errs...)
 }
 
 // CreateIndexWithFields : Helper function to create an index with specified name and fields
 func CreateIndexWithFields(collection *mongo.Collection, indexName string, indexes map[string]interface{}) error {
-	// Drop the index if it already exists
-	err := DropIndex(collection, indexName)
-	if err != nil {
-		return err
-	}
+	return CreateIndexWithFieldsWithContext(context.Background(), collection, indexName, indexes)
+}
 
-	// Convert the map to bson.D format for the Keys
-	keys := bson.D{}
-	for field, value := range indexes {
-		keys = append(keys, bson.E{Key: field, Value: value})
+// CreateIndexWithFieldsWithContext : Same as CreateIndexWithFields but bounded by the caller's context.
+// The index is left alone when an identical one exists. Map keys have no order, so fields are sorted by name;
+// declare an IndexSpec with EnsureIndexes when a compound index needs a specific key order.
+func CreateIndexWithFieldsWithContext(ctx context.Context, collection *mongo.Collection, indexName string, indexes map[string]interface{}) error {
+	fields := make([]string, 0, len(indexes))
+	for field := range indexes {
+		fields = append(fields, field)
 	}
+	sort.Strings(fields)
 
-	// Create the IndexModel with the specified keys and options
-	indexModel := mongo.IndexModel{
-		Keys: keys,
-		Options: options.Index().SetName(indexName).SetDefaultLanguage("english").
-			SetLanguageOverride("custom_language"),
+	spec := IndexSpec{Name: indexName}
+	for _, field := range fields {
+		spec.Keys = append(spec.Keys, bson.E{Key: field, Value: indexes[field]})
 	}
 
-	// Set a timeout context for the index creation operation
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+	// Language options only mean something on text indexes
+	if spec.isText() {
+		spec.DefaultLanguage = "english"
+		spec.LanguageOverride = "custom_language"
+	}
 
-	// Create the index on the specified collection
-	_, err = collection.Indexes().CreateOne(ctx, indexModel)
+	_, err := EnsureIndexes(ctx, collection, []IndexSpec{spec})
 	return err
 }
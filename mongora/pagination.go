@@ -0,0 +1,169 @@
+package mongora
+
+import (
+	"context"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Page : One page of results together with the totals needed to render pagination controls
+type Page[T any] struct {
+	Items      []T    `json:"items"`
+	TotalCount int64  `json:"total_count"`
+	PageIndex  int64  `json:"page_index"`
+	PageSize   int64  `json:"page_size"`
+	TotalPages int64  `json:"total_pages"`
+	Next       string `json:"next,omitempty"`
+	Prev       string `json:"prev,omitempty"`
+}
+
+var defaultPageSize int64 = 10
+
+// SetDefaultPageSize : Page size used when the context carries no limit, non-positive sizes are ignored
+func SetDefaultPageSize(size int64) {
+	if size > 0 {
+		defaultPageSize = size
+	}
+}
+
+func GetDefaultPageSize() int64 { return defaultPageSize }
+
+// FindPage : Find one page of documents and count every document matching the filter
+func FindPage(ctx context.Context, collection *mongo.Collection, filter interface{}) (*Page[bson.M], error) {
+	return findPage[bson.M](ctx, collection, filter, "")
+}
+
+// FindPageWithAddonFields : Same as FindPage but always includes the given addon fields in the projection
+func FindPageWithAddonFields(ctx context.Context, collection *mongo.Collection, filter interface{}, addonFields string) (*Page[bson.M], error) {
+	return findPage[bson.M](ctx, collection, filter, addonFields)
+}
+
+// FindPage : Typed variant of FindPage
+func (r *Repository[T]) FindPage(ctx context.Context, filter interface{}) (*Page[T], error) {
+	return findPage[T](ctx, r.collection, filter, "")
+}
+
+func findPage[T any](ctx context.Context, collection *mongo.Collection, filter interface{}, addonFields string) (*Page[T], error) {
+	if filter == nil {
+		filter = bson.D{}
+	}
+
+	// Pages always have a size, unlike Find which only pages when the context asks for it
+	skip, limit := resolvePaging(ctx)
+	items, err := findPageItems[T](ctx, collection, filter, addonFields, skip, limit)
+	if err != nil {
+		return nil, err
+	}
+	if items == nil {
+		items = []T{}
+	}
+
+	countCtx, cancel := withTimeout(ctx, collection)
+	defer cancel()
+
+	totalCount, err := collection.CountDocuments(countCtx, scopeFilter(ctx, collection, filter))
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	page := &Page[T]{
+		Items:      items,
+		TotalCount: totalCount,
+		PageIndex:  skip / limit,
+		PageSize:   limit,
+		TotalPages: (totalCount + limit - 1) / limit,
+	}
+
+	// Links move by skip rather than page index so an offset that is not a multiple of limit never repeats items
+	if skip+limit < totalCount {
+		page.Next = pageQueryString(ctx, skip+limit, limit)
+	}
+	if skip > 0 {
+		page.Prev = pageQueryString(ctx, max(skip-limit, 0), limit)
+	}
+
+	return page, nil
+}
+
+func findPageItems[T any](ctx context.Context, collection *mongo.Collection, filter interface{}, addonFields string, skip int64, limit int64) ([]T, error) {
+	ctx, cancel := withTimeout(ctx, collection)
+	defer cancel()
+
+	opts, err := buildOptionsForQuery(ctx, collection, addonFields)
+	if err != nil {
+		return nil, err
+	}
+	return runFind[T](ctx, collection, filter, opts.SetSkip(skip).SetLimit(limit))
+}
+
+// resolvePaging : Read skip/limit from the context and fall back to the default page size
+func resolvePaging(ctx context.Context) (int64, int64) {
+	skip, limit, _ := contextPaging(ctx)
+	if limit <= 0 {
+		limit = defaultPageSize
+	}
+	return skip, limit
+}
+
+// contextPaging : Read skip/limit from the context, ok is false when it carries no page size at all.
+// Params parsed by QueryMiddleware always carry one.
+func contextPaging(ctx context.Context) (int64, int64, bool) {
+	skip := contextInt(ctx, "skip")
+	limit := contextInt(ctx, "limit")
+
+	// Contexts filled by older glue code may only carry the 0-based page_index and count_per_page
+	if limit <= 0 {
+		limit = contextInt(ctx, "count_per_page")
+	}
+	if skip <= 0 && limit > 0 {
+		skip = contextInt(ctx, "page_index") * limit
+	}
+
+	if skip < 0 {
+		skip = 0
+	}
+	return skip, limit, limit > 0
+}
+
+// pageQueryString : The request's query string pointing at the page starting at skip. Filters and every other
+// param are kept, only the paging keys are replaced. Offsets that are not a multiple of the page size are
+// written as skip/limit instead of page_index/count_per_page.
+func pageQueryString(ctx context.Context, skip int64, pageSize int64) string {
+	values := url.Values{}
+	if params, ok := QueryParamsFromContext(ctx); ok && params.Values != nil {
+		for key, value := range params.Values {
+			values[key] = append([]string{}, value...)
+		}
+	} else {
+		// Contexts filled by older glue code only carry the sort and projection keys
+		fields := contextString(ctx, "fields")
+		if fields == "" {
+			fields = contextString(ctx, "projection")
+		}
+		for key, value := range map[string]string{
+			"order_by": contextString(ctx, "order_by"),
+			"order":    contextString(ctx, "order"),
+			"sort":     contextString(ctx, "sort"),
+			"fields":   strings.ReplaceAll(fields, " ", ""),
+		} {
+			if value != "" {
+				values.Set(key, value)
+			}
+		}
+	}
+
+	for _, key := range []string{"page_index", "count_per_page", "skip", "limit"} {
+		values.Del(key)
+	}
+	if skip%pageSize != 0 {
+		values.Set("skip", strconv.FormatInt(skip, 10))
+		values.Set("limit", strconv.FormatInt(pageSize, 10))
+	} else {
+		values.Set("page_index", strconv.FormatInt(skip/pageSize, 10))
+		values.Set("count_per_page", strconv.FormatInt(pageSize, 10))
+	}
+	return values.Encode()
+}
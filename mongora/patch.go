@@ -0,0 +1,402 @@
+package mongora
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ProtectedFields : Paths a patch can never write, on top of PatchOptions.Protected
+var ProtectedFields = []string{
+	"_id",
+	CreatedAtField, UpdatedAtField, CreatedByField, UpdatedByField,
+	DeletedAtField, DeletedByField,
+}
+
+// PatchOptions : Which document paths a patch may touch
+type PatchOptions struct {
+	// Allowed lists the writable paths, a listed path also allows everything below it.
+	// Nil allows every path that is not protected.
+	Allowed []string
+
+	// Protected adds paths to ProtectedFields
+	Protected []string
+}
+
+// JSONPatchOperation : One operation of an RFC 6902 JSON Patch
+type JSONPatchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// CompileMergePatch : Turn an RFC 7396 merge patch into an update. Nulls become $unset, nested objects are
+// merged field by field and every other value, arrays included, is $set as a whole.
+func CompileMergePatch(patch []byte, opts PatchOptions) (bson.D, error) {
+	document, err := decodePatchValue(patch)
+	if err != nil {
+		return nil, err
+	}
+	object, ok := document.(map[string]interface{})
+	if !ok {
+		return nil, &ValidationError{Message: "Invalid patch: a merge patch must be a JSON object"}
+	}
+
+	builder := newUpdateBuilder(opts)
+	if err := builder.mergeObject("", object); err != nil {
+		return nil, err
+	}
+	return builder.build(), nil
+}
+
+// CompileJSONPatch : Turn an RFC 6902 JSON Patch into an update and the conditions its test operations require.
+// add and replace become $set, or $push when adding to the end ("-") or at an index of an array;
+// remove becomes $unset, or $pull when the operation carries a value; move becomes $rename.
+// Removing an array element by index and copy need the current document and are rejected.
+func CompileJSONPatch(patch []byte, opts PatchOptions) (bson.D, bson.D, error) {
+	var operations []JSONPatchOperation
+	if err := json.Unmarshal(patch, &operations); err != nil {
+		return nil, nil, &ValidationError{Message: "Invalid patch: a JSON Patch must be an array of operations"}
+	}
+
+	builder := newUpdateBuilder(opts)
+	conditions := bson.D{}
+	for i, operation := range operations {
+		if err := builder.applyOperation(operation, &conditions); err != nil {
+			return nil, nil, fmt.Errorf("operation %d: %w", i, err)
+		}
+	}
+	return builder.build(), conditions, nil
+}
+
+// ApplyMergePatch : Compile a merge patch and apply it to the first matching document, returning it after the update.
+// The collection's version field is protected as well.
+func ApplyMergePatch(ctx context.Context, collection *mongo.Collection, filter interface{}, patch []byte, opts PatchOptions) (bson.M, error) {
+	update, err := CompileMergePatch(patch, collectionPatchOptions(collection, opts))
+	if err != nil {
+		return nil, err
+	}
+	return FindOneAndUpdateWithContext(ctx, collection, filter, update)
+}
+
+// ApplyJSONPatch : Compile a JSON Patch and apply it to the first matching document whose values pass every
+// test operation. A failed test reports ErrNotFound, the same as a missing document.
+func ApplyJSONPatch(ctx context.Context, collection *mongo.Collection, filter interface{}, patch []byte, opts PatchOptions) (bson.M, error) {
+	update, conditions, err := CompileJSONPatch(patch, collectionPatchOptions(collection, opts))
+	if err != nil {
+		return nil, err
+	}
+	if len(conditions) > 0 {
+		filter = andFilter(filter, conditions)
+	}
+	return FindOneAndUpdateWithContext(ctx, collection, filter, update)
+}
+
+func collectionPatchOptions(collection *mongo.Collection, opts PatchOptions) PatchOptions {
+	if versionField := GetCollectionOptions(collection).VersionField; versionField != "" {
+		opts.Protected = append(append([]string{}, opts.Protected...), versionField)
+	}
+	return opts
+}
+
+// updateBuilder : Collects update operators, a later write to the same path replaces the earlier one
+type updateBuilder struct {
+	opts      PatchOptions
+	operators map[string]bson.D
+	order     []string
+}
+
+func newUpdateBuilder(opts PatchOptions) *updateBuilder {
+	return &updateBuilder{opts: opts, operators: map[string]bson.D{}}
+}
+
+func (b *updateBuilder) mergeObject(prefix string, object map[string]interface{}) error {
+	// Sorted keys keep the compiled update stable
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if key == "" || strings.Contains(key, ".") || strings.HasPrefix(key, "$") {
+			return &ValidationError{Message: fmt.Sprintf("Invalid patch: field name '%s' is not allowed", key)}
+		}
+
+		path := key
+		if prefix != "" {
+			path = prefix + "." + key
+		}
+
+		switch value := object[key].(type) {
+		case nil:
+			if err := b.write("$unset", path, ""); err != nil {
+				return err
+			}
+		case map[string]interface{}:
+			if err := b.mergeObject(path, value); err != nil {
+				return err
+			}
+		default:
+			if err := b.write("$set", path, value); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (b *updateBuilder) applyOperation(operation JSONPatchOperation, conditions *bson.D) error {
+	segments, err := parseJSONPointer(operation.Path)
+	if err != nil {
+		return err
+	}
+	path := strings.Join(segments, ".")
+
+	switch operation.Op {
+	case "add", "replace":
+		value, err := decodePatchValue(operation.Value)
+		if err != nil {
+			return err
+		}
+
+		last := segments[len(segments)-1]
+		parent := strings.Join(segments[:len(segments)-1], ".")
+		if operation.Op == "add" && parent != "" && last == "-" {
+			return b.push(parent, value, -1)
+		}
+		if operation.Op == "add" && parent != "" && isArrayIndex(last) {
+			position, _ := strconv.Atoi(last)
+			return b.push(parent, value, position)
+		}
+		return b.write("$set", path, value)
+
+	case "remove":
+		// $unset on an array index leaves a null behind instead of removing the element
+		if last := segments[len(segments)-1]; len(segments) > 1 && isArrayIndex(last) {
+			return &ValidationError{Message: fmt.Sprintf("Invalid patch: removing array element '%s' by index is not supported, remove it by value", operation.Path)}
+		}
+		if len(operation.Value) > 0 {
+			value, err := decodePatchValue(operation.Value)
+			if err != nil {
+				return err
+			}
+			return b.write("$pull", path, value)
+		}
+		return b.write("$unset", path, "")
+
+	case "move":
+		fromSegments, err := parseJSONPointer(operation.From)
+		if err != nil {
+			return err
+		}
+		from := strings.Join(fromSegments, ".")
+		if err := b.checkPath(from); err != nil {
+			return err
+		}
+		if err := b.checkPath(path); err != nil {
+			return err
+		}
+		if pathsOverlap(from, path) {
+			return &ValidationError{Message: fmt.Sprintf("Invalid patch: cannot move '%s' to '%s'", operation.From, operation.Path)}
+		}
+		return b.write("$rename", from, path)
+
+	case "test":
+		if err := b.checkReadable(path); err != nil {
+			return err
+		}
+		value, err := decodePatchValue(operation.Value)
+		if err != nil {
+			return err
+		}
+		// $eq keeps a value such as {"$ne": null} a literal to compare against instead of a query operator
+		*conditions = append(*conditions, bson.E{Key: path, Value: bson.D{{Key: "$eq", Value: value}}})
+		return nil
+
+	case "copy":
+		return &ValidationError{Message: "Invalid patch: copy is not supported"}
+	}
+
+	return &ValidationError{Message: fmt.Sprintf("Invalid patch: unknown operation '%s'", operation.Op)}
+}
+
+// push : Append values with $push/$each, consecutive appends to the same array are combined
+func (b *updateBuilder) push(path string, value interface{}, position int) error {
+	if position < 0 {
+		for i, elem := range b.operators["$push"] {
+			each, ok := elem.Value.(bson.D)
+			if elem.Key == path && ok && len(each) == 1 {
+				each[0].Value = append(each[0].Value.(bson.A), value)
+				b.operators["$push"][i].Value = each
+				return nil
+			}
+		}
+		return b.write("$push", path, bson.D{{Key: "$each", Value: bson.A{value}}})
+	}
+
+	return b.write("$push", path, bson.D{{Key: "$each", Value: bson.A{value}}, {Key: "$position", Value: position}})
+}
+
+// write : Add path to an operator after the allowlist check. The same path written again replaces the
+// earlier write; a path nested in another written path would make MongoDB reject the update.
+// A $rename also writes its destination, which takes part in the same checks.
+func (b *updateBuilder) write(operator string, path string, value interface{}) error {
+	if err := b.checkPath(path); err != nil {
+		return err
+	}
+
+	for name, fields := range b.operators {
+		for i := 0; i < len(fields); i++ {
+			existing := fields[i].Key
+			if existing == path && name == "$push" && operator == "$push" {
+				return &ValidationError{Message: fmt.Sprintf("Invalid patch: only one insert position per array, got several for '%s'", path)}
+			}
+			if existing == path && name != "$rename" && operator != "$rename" {
+				fields = append(fields[:i], fields[i+1:]...)
+				i--
+				continue
+			}
+			for _, written := range renamedPaths(name, fields[i]) {
+				for _, target := range renamedPaths(operator, bson.E{Key: path, Value: value}) {
+					if pathsOverlap(written, target) {
+						return &ValidationError{Message: fmt.Sprintf("Invalid patch: '%s' and '%s' overlap", written, target)}
+					}
+				}
+			}
+		}
+		b.operators[name] = fields
+	}
+
+	if _, exists := b.operators[operator]; !exists {
+		b.order = append(b.order, operator)
+	}
+	b.operators[operator] = append(b.operators[operator], bson.E{Key: path, Value: value})
+	return nil
+}
+
+// renamedPaths : Every path an operator entry writes, a $rename writes both its source and its destination
+func renamedPaths(operator string, elem bson.E) []string {
+	if destination, ok := elem.Value.(string); ok && operator == "$rename" {
+		return []string{elem.Key, destination}
+	}
+	return []string{elem.Key}
+}
+
+// pathsOverlap : The paths are the same or one is nested in the other
+func pathsOverlap(a string, b string) bool {
+	return a == b || strings.HasPrefix(a, b+".") || strings.HasPrefix(b, a+".")
+}
+
+func (b *updateBuilder) build() bson.D {
+	update := bson.D{}
+	for _, operator := range b.order {
+		if fields := b.operators[operator]; len(fields) > 0 {
+			update = append(update, bson.E{Key: operator, Value: fields})
+		}
+	}
+	return update
+}
+
+// checkPath : A path is writable when it is not, or does not contain, a protected field and sits under an allowed path
+func (b *updateBuilder) checkPath(path string) error {
+	if path == "" {
+		return &ValidationError{Message: "Invalid patch: the whole document cannot be replaced"}
+	}
+
+	for _, protected := range append(append([]string{}, ProtectedFields...), b.opts.Protected...) {
+		if pathsOverlap(path, protected) {
+			return &ValidationError{Message: "Invalid patch", Fields: []FieldError{{
+				Field: path, Tag: "protected", Message: fmt.Sprintf("%s cannot be modified", path),
+			}}}
+		}
+	}
+
+	if b.opts.Allowed == nil {
+		return nil
+	}
+	for _, allowed := range b.opts.Allowed {
+		if path == allowed || strings.HasPrefix(path, allowed+".") {
+			return nil
+		}
+	}
+	return &ValidationError{Message: "Invalid patch", Fields: []FieldError{{
+		Field: path, Tag: "allowed", Message: fmt.Sprintf("%s cannot be modified", path),
+	}}}
+}
+
+// checkReadable : test operations only read, but still may not probe fields outside the allowlist
+func (b *updateBuilder) checkReadable(path string) error {
+	if b.opts.Allowed == nil || path == "_id" {
+		return nil
+	}
+	return b.checkPath(path)
+}
+
+// parseJSONPointer : Split an RFC 6901 pointer into field names, "/title/en" gives ["title", "en"]
+func parseJSONPointer(pointer string) ([]string, error) {
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, &ValidationError{Message: fmt.Sprintf("Invalid patch: path '%s' must start with /", pointer)}
+	}
+
+	segments := strings.Split(pointer[1:], "/")
+	for i, segment := range segments {
+		segment = strings.ReplaceAll(strings.ReplaceAll(segment, "~1", "/"), "~0", "~")
+		if segment == "" || strings.Contains(segment, ".") || strings.HasPrefix(segment, "$") {
+			return nil, &ValidationError{Message: fmt.Sprintf("Invalid patch: path '%s' is not allowed", pointer)}
+		}
+		segments[i] = segment
+	}
+	return segments, nil
+}
+
+func isArrayIndex(segment string) bool {
+	if segment == "0" {
+		return true
+	}
+	_, err := strconv.Atoi(segment)
+	return err == nil && !strings.HasPrefix(segment, "0") && !strings.HasPrefix(segment, "-")
+}
+
+// decodePatchValue : Decode JSON keeping integers as int64 instead of float64
+func decodePatchValue(raw []byte) (interface{}, error) {
+	if len(raw) == 0 {
+		return nil, &ValidationError{Message: "Invalid patch: missing value"}
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, &ValidationError{Message: "Invalid patch: Invalid JSON format"}
+	}
+	return convertPatchNumbers(value), nil
+}
+
+func convertPatchNumbers(value interface{}) interface{} {
+	switch v := value.(type) {
+	case json.Number:
+		if integer, err := v.Int64(); err == nil {
+			return integer
+		}
+		float, _ := v.Float64()
+		return float
+	case map[string]interface{}:
+		for key, elem := range v {
+			v[key] = convertPatchNumbers(elem)
+		}
+	case []interface{}:
+		for i, elem := range v {
+			v[i] = convertPatchNumbers(elem)
+		}
+	}
+	return value
+}
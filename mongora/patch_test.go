@@ -0,0 +1,130 @@
+package mongora
+
+import (
+	"testing"
+)
+
+func TestCompileMergePatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		patch   string
+		opts    PatchOptions
+		want    string
+		wantErr bool
+	}{
+		{
+			name:  "nulls unset and objects merge",
+			patch: `{"title":{"en":"Hi","mm":null},"plays":3,"tags":["a"]}`,
+			want:  `{"$set":{"plays":{"$numberLong":"3"},"tags":["a"],"title.en":"Hi"},"$unset":{"title.mm":""}}`,
+		},
+		{name: "_id is protected", patch: `{"_id":"x"}`, wantErr: true},
+		{name: "audit fields are protected", patch: `{"created_at":1}`, wantErr: true},
+		{name: "nested write into a protected field", patch: `{"deleted_at":{"x":1}}`, wantErr: true},
+		{name: "extra protected field", patch: `{"version":2}`, opts: PatchOptions{Protected: []string{"version"}}, wantErr: true},
+		{
+			name:  "allowed parent allows children",
+			patch: `{"title":{"en":"x"}}`,
+			opts:  PatchOptions{Allowed: []string{"title"}},
+			want:  `{"$set":{"title.en":"x"}}`,
+		},
+		{name: "outside the allowlist", patch: `{"title":"x","secret":1}`, opts: PatchOptions{Allowed: []string{"title"}}, wantErr: true},
+		{name: "dotted field name", patch: `{"a.b":1}`, wantErr: true},
+		{name: "operator field name", patch: `{"$set":{"a":1}}`, wantErr: true},
+		{name: "not an object", patch: `[1]`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			update, err := CompileMergePatch([]byte(tt.patch), tt.opts)
+			if tt.wantErr {
+				assertValidationError(t, err)
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := extJSON(t, update); got != tt.want {
+				t.Errorf("update = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompileJSONPatch(t *testing.T) {
+	tests := []struct {
+		name           string
+		patch          string
+		opts           PatchOptions
+		want           string
+		wantConditions string
+		wantErr        bool
+	}{
+		{
+			name:           "set, push, unset, pull and test",
+			patch:          `[{"op":"test","path":"/version","value":3},{"op":"replace","path":"/title/en","value":"x"},{"op":"add","path":"/tags/-","value":"a"},{"op":"add","path":"/tags/-","value":"b"},{"op":"remove","path":"/old"},{"op":"remove","path":"/labels","value":"x"}]`,
+			want:           `{"$set":{"title.en":"x"},"$push":{"tags":{"$each":["a","b"]}},"$unset":{"old":""},"$pull":{"labels":"x"}}`,
+			wantConditions: `{"version":{"$eq":{"$numberLong":"3"}}}`,
+		},
+		{
+			name:  "insert at an index",
+			patch: `[{"op":"add","path":"/tags/0","value":"a"}]`,
+			want:  `{"$push":{"tags":{"$each":["a"],"$position":{"$numberInt":"0"}}}}`,
+		},
+		{
+			name:  "move",
+			patch: `[{"op":"move","from":"/a","path":"/b"}]`,
+			want:  `{"$rename":{"a":"b"}}`,
+		},
+		{
+			name:           "test values stay literal",
+			patch:          `[{"op":"test","path":"/status","value":{"$ne":null}}]`,
+			want:           `{}`,
+			wantConditions: `{"status":{"$eq":{"$ne":null}}}`,
+		},
+		{name: "replace _id", patch: `[{"op":"replace","path":"/_id","value":1}]`, wantErr: true},
+		{name: "remove an audit field", patch: `[{"op":"remove","path":"/updated_by"}]`, wantErr: true},
+		{name: "move into an audit field", patch: `[{"op":"move","from":"/title","path":"/created_at"}]`, wantErr: true},
+		{name: "move out of _id", patch: `[{"op":"move","from":"/_id","path":"/b"}]`, wantErr: true},
+		{
+			name:    "move outside the allowlist",
+			patch:   `[{"op":"move","from":"/title","path":"/secret"}]`,
+			opts:    PatchOptions{Allowed: []string{"title"}},
+			wantErr: true,
+		},
+		{
+			name:    "test outside the allowlist",
+			patch:   `[{"op":"test","path":"/secret","value":1}]`,
+			opts:    PatchOptions{Allowed: []string{"title"}},
+			wantErr: true,
+		},
+		{name: "move into itself", patch: `[{"op":"move","from":"/a","path":"/a/b"}]`, wantErr: true},
+		{name: "write over a move destination", patch: `[{"op":"move","from":"/a","path":"/b"},{"op":"replace","path":"/b","value":1}]`, wantErr: true},
+		{name: "remove by index", patch: `[{"op":"remove","path":"/tags/0"}]`, wantErr: true},
+		{name: "two insert positions", patch: `[{"op":"add","path":"/tags/0","value":"a"},{"op":"add","path":"/tags/1","value":"b"}]`, wantErr: true},
+		{name: "overlapping paths", patch: `[{"op":"replace","path":"/a","value":1},{"op":"replace","path":"/a/b","value":1}]`, wantErr: true},
+		{name: "copy", patch: `[{"op":"copy","from":"/a","path":"/b"}]`, wantErr: true},
+		{name: "operator in path", patch: `[{"op":"replace","path":"/$where","value":1}]`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			update, conditions, err := CompileJSONPatch([]byte(tt.patch), tt.opts)
+			if tt.wantErr {
+				assertValidationError(t, err)
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := extJSON(t, update); got != tt.want {
+				t.Errorf("update = %s, want %s", got, tt.want)
+			}
+			if tt.wantConditions == "" {
+				tt.wantConditions = `{}`
+			}
+			if got := extJSON(t, conditions); got != tt.wantConditions {
+				t.Errorf("conditions = %s, want %s", got, tt.wantConditions)
+			}
+		})
+	}
+}
@@ -0,0 +1,216 @@
+package mongora
+
+import (
+	"context"
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"net/url"
+	"sort"
+)
+
+// Pipeline : Fluent builder for aggregation pipelines, the first parse error is reported by Build
+type Pipeline struct {
+	stages mongo.Pipeline
+	err    error
+}
+
+// NewPipeline : Create an empty aggregation pipeline
+func NewPipeline() *Pipeline {
+	return &Pipeline{stages: mongo.Pipeline{}}
+}
+
+// Stage : Append a raw stage for operators the builder does not cover
+func (p *Pipeline) Stage(stage bson.D) *Pipeline {
+	p.stages = append(p.stages, stage)
+	return p
+}
+
+func (p *Pipeline) Match(filter interface{}) *Pipeline {
+	return p.Stage(bson.D{{Key: "$match", Value: filter}})
+}
+
+func (p *Pipeline) Project(projection interface{}) *Pipeline {
+	return p.Stage(bson.D{{Key: "$project", Value: projection}})
+}
+
+// Group : $group on id with the given accumulators, e.g. bson.D{{"total", bson.D{{"$sum", 1}}}}
+func (p *Pipeline) Group(id interface{}, accumulators bson.D) *Pipeline {
+	group := bson.D{{Key: "_id", Value: id}}
+	group = append(group, accumulators...)
+	return p.Stage(bson.D{{Key: "$group", Value: group}})
+}
+
+func (p *Pipeline) Sort(sortOrder bson.D) *Pipeline {
+	return p.Stage(bson.D{{Key: "$sort", Value: sortOrder}})
+}
+
+// Lookup : Left outer join with another collection on localField == foreignField
+func (p *Pipeline) Lookup(from string, localField string, foreignField string, as string) *Pipeline {
+	return p.Stage(bson.D{{Key: "$lookup", Value: bson.D{
+		{Key: "from", Value: from},
+		{Key: "localField", Value: localField},
+		{Key: "foreignField", Value: foreignField},
+		{Key: "as", Value: as},
+	}}})
+}
+
+// Unwind : Deconstruct an array field, path may be given with or without the leading $
+func (p *Pipeline) Unwind(path string, preserveNullAndEmptyArrays bool) *Pipeline {
+	if len(path) > 0 && path[0] != '$' {
+		path = "$" + path
+	}
+
+	return p.Stage(bson.D{{Key: "$unwind", Value: bson.D{
+		{Key: "path", Value: path},
+		{Key: "preserveNullAndEmptyArrays", Value: preserveNullAndEmptyArrays},
+	}}})
+}
+
+// Facet : Run several sub-pipelines over the same input, facets are emitted in name order
+func (p *Pipeline) Facet(facets map[string]*Pipeline) *Pipeline {
+	names := make([]string, 0, len(facets))
+	for name := range facets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	facet := bson.D{}
+	for _, name := range names {
+		stages, err := facets[name].Build()
+		if err != nil {
+			p.setErr(fmt.Errorf("facet %s: %w", name, err))
+		}
+		facet = append(facet, bson.E{Key: name, Value: stages})
+	}
+
+	return p.Stage(bson.D{{Key: "$facet", Value: facet}})
+}
+
+func (p *Pipeline) AddFields(fields bson.D) *Pipeline {
+	return p.Stage(bson.D{{Key: "$addFields", Value: fields}})
+}
+
+// Count : Replace the input with a single document holding the number of documents in field
+func (p *Pipeline) Count(field string) *Pipeline {
+	return p.Stage(bson.D{{Key: "$count", Value: field}})
+}
+
+func (p *Pipeline) Skip(skip int64) *Pipeline {
+	return p.Stage(bson.D{{Key: "$skip", Value: skip}})
+}
+
+func (p *Pipeline) Limit(limit int64) *Pipeline {
+	return p.Stage(bson.D{{Key: "$limit", Value: limit}})
+}
+
+// Bucket : Categorize documents by groupBy into the given boundaries, defaultBucket and output are optional
+func (p *Pipeline) Bucket(groupBy interface{}, boundaries []interface{}, defaultBucket interface{}, output bson.D) *Pipeline {
+	bucket := bson.D{
+		{Key: "groupBy", Value: groupBy},
+		{Key: "boundaries", Value: boundaries},
+	}
+	if defaultBucket != nil {
+		bucket = append(bucket, bson.E{Key: "default", Value: defaultBucket})
+	}
+	if len(output) > 0 {
+		bucket = append(bucket, bson.E{Key: "output", Value: output})
+	}
+
+	return p.Stage(bson.D{{Key: "$bucket", Value: bucket}})
+}
+
+// MatchQuery : $match compiled from query params with ParseFilter
+func (p *Pipeline) MatchQuery(queryParams url.Values, fields FilterFields) *Pipeline {
+	filter, err := ParseFilter(queryParams, fields)
+	if err != nil {
+		p.setErr(err)
+		return p
+	}
+
+	if len(filter) > 0 {
+		p.Match(filter)
+	}
+	return p
+}
+
+// ProjectFromContext : $project built from the `fields` context key and the collection allowlist
+func (p *Pipeline) ProjectFromContext(ctx context.Context, collection *mongo.Collection) *Pipeline {
+	fields := contextString(ctx, "fields")
+	if fields == "" {
+		return p
+	}
+
+	projection, err := BuildProjection(fields, GetCollectionOptions(collection).ProjectionFields)
+	if err != nil {
+		p.setErr(err)
+		return p
+	}
+	return p.Project(projection)
+}
+
+// SortFromContext : $sort built from the `sort` or order_by/order context keys and the collection allowlist
+func (p *Pipeline) SortFromContext(ctx context.Context, collection *mongo.Collection) *Pipeline {
+	sortOrder, err := sortFromContext(ctx, GetCollectionOptions(collection).SortFields)
+	if err != nil {
+		p.setErr(err)
+		return p
+	}
+
+	if len(sortOrder) > 0 {
+		p.Sort(sortOrder)
+	}
+	return p
+}
+
+// PageFromContext : $skip and $limit from the skip/limit context keys
+func (p *Pipeline) PageFromContext(ctx context.Context) *Pipeline {
+	skip, limit := resolvePaging(ctx)
+	if skip > 0 {
+		p.Skip(skip)
+	}
+	return p.Limit(limit)
+}
+
+// Build : Return the stages or the first error recorded while building
+func (p *Pipeline) Build() (mongo.Pipeline, error) {
+	if p.err != nil {
+		return nil, p.err
+	}
+	return p.stages, nil
+}
+
+func (p *Pipeline) setErr(err error) {
+	if p.err == nil {
+		p.err = err
+	}
+}
+
+// Aggregate : Run the pipeline and decode every result document into T
+func Aggregate[T any](ctx context.Context, collection *mongo.Collection, pipeline *Pipeline) ([]T, error) {
+	stages, err := pipeline.Build()
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := withTimeout(ctx, collection)
+	defer cancel()
+
+	cursor, err := collection.Aggregate(ctx, stages)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	defer cursor.Close(ctx)
+
+	results := []T{}
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, fmt.Errorf("mongora: decoding documents: %w", translateError(err))
+	}
+
+	return results, nil
+}
+
+// Aggregate : Run the pipeline on the repository collection, for results shaped like T
+func (r *Repository[T]) Aggregate(ctx context.Context, pipeline *Pipeline) ([]T, error) {
+	return Aggregate[T](ctx, r.collection, pipeline)
+}
@@ -0,0 +1,198 @@
+package mongora
+
+import (
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson"
+	"strconv"
+	"strings"
+)
+
+// BuildProjection : Parse a comma separated field list into a projection and validate it against the allowlist.
+//
+//	name,title.en           include fields, dotted paths are allowed
+//	-_id,name               _id may be excluded from an inclusion projection
+//	-password,-secret       exclude fields
+//	comments:slice(5)       $slice, also slice(-5) and slice(10:5) for skip:limit
+//	items:elemMatch(a=b;c=d) $elemMatch on string values
+//
+// An empty allowlist permits every field.
+func BuildProjection(fields string, allowed []string) (bson.D, error) {
+	projection := bson.D{}
+	var fieldErrors []FieldError
+	hasInclusion, hasExclusion := false, false
+
+	for _, part := range strings.Split(fields, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		// Array operators such as comments:slice(5)
+		if field, operator, found := strings.Cut(part, ":"); found {
+			value, err := parseArrayProjection(operator)
+			if err == nil && !isProjectionFieldAllowed(field, allowed) {
+				err = fmt.Errorf("projecting '%s' is not allowed", field)
+			}
+			if err != nil {
+				fieldErrors = append(fieldErrors, FieldError{Field: field, Tag: "projection", Param: operator, Message: err.Error()})
+				continue
+			}
+
+			projection = append(projection, bson.E{Key: field, Value: value})
+			continue
+		}
+
+		value := 1
+		if strings.HasPrefix(part, "-") {
+			value = 0
+			part = part[1:]
+		} else {
+			part = strings.TrimPrefix(part, "+")
+		}
+
+		if part == "" || strings.HasPrefix(part, "$") || strings.HasSuffix(part, ".") {
+			fieldErrors = append(fieldErrors, FieldError{Field: part, Tag: "projection", Message: fmt.Sprintf("invalid projection field '%s'", part)})
+			continue
+		}
+		if !isProjectionFieldAllowed(part, allowed) {
+			fieldErrors = append(fieldErrors, FieldError{Field: part, Tag: "projection", Message: fmt.Sprintf("projecting '%s' is not allowed", part)})
+			continue
+		}
+
+		// Excluding _id is the one exclusion MongoDB accepts inside an inclusion projection
+		if value == 1 {
+			hasInclusion = true
+		} else if part != "_id" {
+			hasExclusion = true
+		}
+
+		projection = append(projection, bson.E{Key: part, Value: value})
+	}
+
+	if hasInclusion && hasExclusion {
+		fieldErrors = append(fieldErrors, FieldError{Field: "fields", Tag: "projection", Message: "mix of inclusion and exclusion fields is not allowed"})
+	}
+
+	if len(fieldErrors) > 0 {
+		return nil, &ValidationError{Message: "Invalid projection", Fields: fieldErrors}
+	}
+
+	return projection, nil
+}
+
+// mergeProjectionFields : Make sure every addon field survives the requested projection.
+// Inclusion lists get the field appended, exclusion lists drop the matching exclusion.
+func mergeProjectionFields(fields string, addonFields string) string {
+	if strings.TrimSpace(fields) == "" || strings.TrimSpace(addonFields) == "" {
+		return fields
+	}
+
+	parts := splitProjectionFields(fields)
+	isExclusion := true
+	for _, part := range parts {
+		if !strings.HasPrefix(part, "-") {
+			isExclusion = false
+			break
+		}
+	}
+
+	for _, addon := range splitProjectionFields(addonFields) {
+		addon = strings.TrimPrefix(addon, "+")
+		if isExclusion {
+			parts = removeProjectionField(parts, "-"+addon)
+			continue
+		}
+
+		if !containsProjectionField(parts, addon) {
+			parts = append(parts, addon)
+		}
+	}
+
+	return strings.Join(parts, ",")
+}
+
+func splitProjectionFields(fields string) []string {
+	var parts []string
+	for _, part := range strings.Split(fields, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return parts
+}
+
+// containsProjectionField : Exact match on the field name, ignoring the +/- prefix and array operators
+func containsProjectionField(parts []string, field string) bool {
+	for _, part := range parts {
+		name, _, _ := strings.Cut(strings.TrimLeft(part, "+-"), ":")
+		if name == field {
+			return true
+		}
+	}
+	return false
+}
+
+func removeProjectionField(parts []string, field string) []string {
+	kept := parts[:0]
+	for _, part := range parts {
+		if part != field {
+			kept = append(kept, part)
+		}
+	}
+	return kept
+}
+
+// isProjectionFieldAllowed : An allowed field also allows its sub-paths, so "name" permits "name.en"
+func isProjectionFieldAllowed(field string, allowed []string) bool {
+	if len(allowed) == 0 || field == "_id" {
+		return true
+	}
+
+	for _, allowedField := range allowed {
+		if field == allowedField || strings.HasPrefix(field, allowedField+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// parseArrayProjection : Parse slice(n), slice(skip:limit) and elemMatch(a=b;c=d)
+func parseArrayProjection(operator string) (bson.D, error) {
+	name, args, found := strings.Cut(operator, "(")
+	if !found || !strings.HasSuffix(args, ")") {
+		return nil, fmt.Errorf("malformed array projection '%s'", operator)
+	}
+	args = strings.TrimSuffix(args, ")")
+
+	switch name {
+	case "slice":
+		if skipArg, limitArg, isRange := strings.Cut(args, ":"); isRange {
+			skip, skipErr := strconv.Atoi(strings.TrimSpace(skipArg))
+			limit, limitErr := strconv.Atoi(strings.TrimSpace(limitArg))
+			if skipErr != nil || limitErr != nil || limit <= 0 {
+				return nil, fmt.Errorf("slice expects (count) or (skip:limit), got '%s'", args)
+			}
+			return bson.D{{Key: "$slice", Value: bson.A{skip, limit}}}, nil
+		}
+
+		count, err := strconv.Atoi(strings.TrimSpace(args))
+		if err != nil {
+			return nil, fmt.Errorf("slice expects (count) or (skip:limit), got '%s'", args)
+		}
+		return bson.D{{Key: "$slice", Value: count}}, nil
+
+	case "elemMatch":
+		condition := bson.D{}
+		for _, pair := range strings.Split(args, ";") {
+			key, value, ok := strings.Cut(pair, "=")
+			key = strings.TrimSpace(key)
+			if !ok || key == "" || strings.HasPrefix(key, "$") {
+				return nil, fmt.Errorf("elemMatch expects key=value pairs, got '%s'", args)
+			}
+			condition = append(condition, bson.E{Key: key, Value: strings.TrimSpace(value)})
+		}
+		return bson.D{{Key: "$elemMatch", Value: condition}}, nil
+	}
+
+	return nil, fmt.Errorf("unsupported array projection '%s'", name)
+}
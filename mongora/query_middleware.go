@@ -0,0 +1,179 @@
+package mongora
+
+import (
+	"context"
+	"fmt"
+	goNest "github.com/thetnswe/mongora/go_nest"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// QueryParams : Projection, sort and paging parsed from the request by QueryMiddleware
+type QueryParams struct {
+	Fields  string `json:"fields"`
+	Sort    string `json:"sort"`
+	OrderBy string `json:"order_by"`
+	Order   string `json:"order"`
+
+	// PageIndex is 0-based, Skip and Limit are derived from it and PageSize. With a raw skip that is not a
+	// multiple of limit, PageIndex is the page Skip falls in and Skip stays the exact offset.
+	PageIndex int64 `json:"page_index"`
+	PageSize  int64 `json:"count_per_page"`
+	Skip      int64 `json:"skip"`
+	Limit     int64 `json:"limit"`
+
+	// Values are the raw query params, FindPage keeps them in its next/prev links
+	Values url.Values `json:"-"`
+}
+
+// QueryMiddlewareOptions : Page size policy, zero values use the package default page size and a maximum of 100
+type QueryMiddlewareOptions struct {
+	DefaultPageSize int64
+	MaxPageSize     int64
+}
+
+var defaultMaxPageSize int64 = 100
+
+// QueryMiddleware : Parse the query params of every request into the context read by Find, FindPage,
+// FindAfter and the pipeline helpers. Malformed paging params are answered with a 422 error envelope.
+func QueryMiddleware(opts ...QueryMiddlewareOptions) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			params, err := ParseQueryParams(r.URL.Query(), opts...)
+			if err != nil {
+				WriteError(w, err)
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(WithQueryParams(r.Context(), params)))
+		})
+	}
+}
+
+// ParseQueryParams : Read fields, sort, order_by, order and either page_index/count_per_page or skip/limit.
+// Page sizes above the maximum are capped rather than rejected.
+func ParseQueryParams(values url.Values, opts ...QueryMiddlewareOptions) (QueryParams, error) {
+	var queryOpts QueryMiddlewareOptions
+	if len(opts) > 0 {
+		queryOpts = opts[0]
+	}
+	if queryOpts.DefaultPageSize <= 0 {
+		queryOpts.DefaultPageSize = GetDefaultPageSize()
+	}
+	if queryOpts.MaxPageSize <= 0 {
+		queryOpts.MaxPageSize = defaultMaxPageSize
+	}
+
+	params := QueryParams{
+		Fields:  values.Get("fields"),
+		Sort:    values.Get("sort"),
+		OrderBy: values.Get("order_by"),
+		Order:   values.Get("order"),
+		Values:  values,
+	}
+	if params.Fields == "" {
+		params.Fields = values.Get("projection")
+	}
+
+	var fieldErrors []FieldError
+	readInt := func(key string) (int64, bool) {
+		raw := values.Get(key)
+		if raw == "" {
+			return 0, false
+		}
+		value, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || value < 0 {
+			fieldErrors = append(fieldErrors, FieldError{
+				Field: key, Tag: "gte", Param: "0",
+				Message: fmt.Sprintf("'%s' must be a non-negative integer, got '%s'", key, raw),
+			})
+			return 0, false
+		}
+		return value, true
+	}
+
+	pageIndex, hasPageIndex := readInt("page_index")
+	pageSize, hasPageSize := readInt("count_per_page")
+	skip, hasSkip := readInt("skip")
+	limit, hasLimit := readInt("limit")
+	if len(fieldErrors) > 0 {
+		return QueryParams{}, &ValidationError{Message: "Invalid paging", Fields: fieldErrors}
+	}
+
+	// count_per_page wins over limit, page_index over skip
+	switch {
+	case hasPageSize && pageSize > 0:
+		params.Limit = pageSize
+	case hasLimit && limit > 0:
+		params.Limit = limit
+	default:
+		params.Limit = queryOpts.DefaultPageSize
+	}
+	params.Limit = min(params.Limit, queryOpts.MaxPageSize)
+
+	switch {
+	case hasPageIndex:
+		params.Skip = pageIndex * params.Limit
+	case hasSkip:
+		params.Skip = skip
+	}
+
+	params.PageIndex = params.Skip / params.Limit
+	params.PageSize = params.Limit
+	return params, nil
+}
+
+// WithQueryParams : Store parsed query params, used by QueryMiddleware and handy in tests and workers
+func WithQueryParams(ctx context.Context, params QueryParams) context.Context {
+	return context.WithValue(ctx, queryParamsKey, params)
+}
+
+// QueryParamsFromContext : Return the params stored by QueryMiddleware or WithQueryParams
+func QueryParamsFromContext(ctx context.Context) (QueryParams, bool) {
+	params, ok := ctx.Value(queryParamsKey).(QueryParams)
+	return params, ok
+}
+
+// contextString : Read a query value from the typed params, falling back to the legacy plain string key
+func contextString(ctx context.Context, key string) string {
+	params, ok := QueryParamsFromContext(ctx)
+	if !ok {
+		return goNest.GetCtxStringValue(ctx, key)
+	}
+
+	switch key {
+	case "fields", "projection":
+		return params.Fields
+	case "sort":
+		return params.Sort
+	case "order_by":
+		return params.OrderBy
+	case "order":
+		return params.Order
+	case "page_index":
+		return strconv.FormatInt(params.PageIndex, 10)
+	case "count_per_page":
+		return strconv.FormatInt(params.PageSize, 10)
+	}
+	return goNest.GetCtxStringValue(ctx, key)
+}
+
+// contextInt : Same as contextString for the numeric paging values
+func contextInt(ctx context.Context, key string) int64 {
+	params, ok := QueryParamsFromContext(ctx)
+	if !ok {
+		return int64(goNest.GetCtxIntValue(ctx, key))
+	}
+
+	switch key {
+	case "skip":
+		return params.Skip
+	case "limit":
+		return params.Limit
+	case "page_index":
+		return params.PageIndex
+	case "count_per_page":
+		return params.PageSize
+	}
+	return int64(goNest.GetCtxIntValue(ctx, key))
+}
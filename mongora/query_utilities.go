@@ -3,108 +3,134 @@ package mongora
 import (
 	"go.mongodb.org/mongo-driver/bson"
 	"net/url"
+	"regexp"
 )
 
+// AppendTextSearchTitleFilter : Add a $text search for the title param, run it with FindRanked to get ranked results
 func AppendTextSearchTitleFilter(queryParams url.Values, filter bson.D) (bson.D, string) {
 	val := queryParams.Get("title")
 	if val != "" {
-		return append(filter, bson.E{Key: "$text", Value: bson.D{{Key: "$search", Value: val}}}), val
+		return append(filter, TextFilter(val)), val
 	}
 
 	return filter, val
 }
 
+// AppendTextSearchNameFilter : Add a $text search for the name param, run it with FindRanked to get ranked results
 func AppendTextSearchNameFilter(queryParams url.Values, filter bson.D) (bson.D, string) {
 	val := queryParams.Get("name")
 	if val != "" {
-		return append(filter, bson.E{Key: "$text", Value: bson.D{{Key: "$search", Value: val}}}), val
+		return append(filter, TextFilter(val)), val
 	}
 
 	return filter, val
 }
 
-// AppendOrFilter : Custom or structure to search the array of given fields and value to search as regex expression
-func AppendRegexOrFilter(filter bson.D, fields []string, val string) (bson.D, string) {
-	if val != "" {
-		// Create an array to store the $or conditions
-		orQueries := bson.A{}
+// MatchMode : How a search value is turned into a $regex
+type MatchMode int
 
-		// Iterate over each field and add a regex condition for it
-		for _, field := range fields {
-			orQueries = append(orQueries, bson.D{{field, bson.D{{"$regex", val}, {"$options", "i"}}}})
-		}
+const (
+	// MatchContains matches the value anywhere in the field
+	MatchContains MatchMode = iota
 
-		// Add the $or array to the filter
-		findQueries := bson.D{
-			{"$or", orQueries},
-		}
+	// MatchPrefix anchors the value at the start, a case-sensitive prefix can use an index
+	MatchPrefix
 
-		return append(filter, findQueries...), val
-	}
+	// MatchExact matches the whole field
+	MatchExact
 
-	return filter, val
+	// MatchRaw passes the value through as a regular expression, only for trusted input
+	MatchRaw
+)
+
+// RegexOptions : Match mode and case sensitivity, the zero value is a case-insensitive contains match
+type RegexOptions struct {
+	Mode          MatchMode
+	CaseSensitive bool
 }
 
-func AppendFindByTitleFilter(queryParams url.Values, filter bson.D) (bson.D, string) {
-	val := queryParams.Get("title")
-	if val != "" {
-		findQueries := bson.D{
-			{"$or", bson.A{
-				bson.D{{"title.en", bson.D{{"$regex", val}, {"$options", "i"}}}},
-				bson.D{{"title.mm", bson.D{{"$regex", val}, {"$options", "i"}}}},
-			}},
-		}
-		return append(filter, findQueries...), val
+// maxRegexInputLength : Search values are cut to this many characters before they reach the server
+var maxRegexInputLength = 100
+
+// SetMaxRegexInputLength : Change the maximum search value length, values below 1 are ignored
+func SetMaxRegexInputLength(length int) {
+	if length > 0 {
+		maxRegexInputLength = length
 	}
+}
 
-	return filter, val
+// GetMaxRegexInputLength : Current maximum search value length
+func GetMaxRegexInputLength() int {
+	return maxRegexInputLength
 }
 
-func AppendFindByTitleAndKeyWordsFilter(queryParams url.Values, filter bson.D) (bson.D, string) {
-	val := queryParams.Get("title")
-	if val != "" {
-		findQueries := bson.D{
-			{"$or", bson.A{
-				bson.D{{"title.en", bson.D{{"$regex", val}, {"$options", "i"}}}},
-				bson.D{{"title.mm", bson.D{{"$regex", val}, {"$options", "i"}}}},
-				bson.D{{"keywords.en", bson.D{{"$regex", val}, {"$options", "i"}}}},
-				bson.D{{"keywords.mm", bson.D{{"$regex", val}, {"$options", "i"}}}},
-			}},
-		}
-		return append(filter, findQueries...), val
+// RegexCondition : Build the {$regex, $options} condition for a value. Regex metacharacters are escaped
+// for every mode except MatchRaw. Returns the value after it was cut to the maximum input length.
+func RegexCondition(val string, opts ...RegexOptions) (bson.D, string) {
+	var regexOpts RegexOptions
+	if len(opts) > 0 {
+		regexOpts = opts[0]
 	}
 
-	return filter, val
-}
+	if letters := []rune(val); len(letters) > maxRegexInputLength {
+		val = string(letters[:maxRegexInputLength])
+	}
 
-func AppendFindByName(queryParams url.Values, filter bson.D) (bson.D, string) {
-	val := queryParams.Get("name")
-	if val != "" {
-		findQueries := bson.D{
-			{"$or", bson.A{
-				bson.D{{"name.en", bson.D{{"$regex", val}, {"$options", "i"}}}},
-				bson.D{{"name.mm", bson.D{{"$regex", val}, {"$options", "i"}}}},
-			}},
-		}
-		return append(filter, findQueries...), val
+	var pattern string
+	switch regexOpts.Mode {
+	case MatchPrefix:
+		pattern = "^" + regexp.QuoteMeta(val)
+	case MatchExact:
+		pattern = "^" + regexp.QuoteMeta(val) + "$"
+	case MatchRaw:
+		pattern = val
+	default:
+		pattern = regexp.QuoteMeta(val)
 	}
 
-	return filter, val
+	condition := bson.D{{Key: "$regex", Value: pattern}}
+	if !regexOpts.CaseSensitive {
+		condition = append(condition, bson.E{Key: "$options", Value: "i"})
+	}
+	return condition, val
 }
 
-func AppendFindByNameAndKeyWordsFilter(queryParams url.Values, filter bson.D) (bson.D, string) {
-	val := queryParams.Get("name")
-	if val != "" {
-		findQueries := bson.D{
-			{"$or", bson.A{
-				bson.D{{"name.en", bson.D{{"$regex", val}, {"$options", "i"}}}},
-				bson.D{{"name.mm", bson.D{{"$regex", val}, {"$options", "i"}}}},
-				bson.D{{"keywords.en", bson.D{{"$regex", val}, {"$options", "i"}}}},
-				bson.D{{"keywords.mm", bson.D{{"$regex", val}, {"$options", "i"}}}},
-			}},
-		}
-		return append(filter, findQueries...), val
+// AppendRegexOrFilter : Custom or structure to search the array of given fields, the value is escaped and matched anywhere
+func AppendRegexOrFilter(filter bson.D, fields []string, val string) (bson.D, string) {
+	return AppendRegexOrFilterWithOptions(filter, fields, val, RegexOptions{})
+}
+
+// AppendRegexOrFilterWithOptions : Same as AppendRegexOrFilter with a choice of match mode and case sensitivity
+func AppendRegexOrFilterWithOptions(filter bson.D, fields []string, val string, opts RegexOptions) (bson.D, string) {
+	if val == "" {
+		return filter, val
 	}
 
-	return filter, val
+	condition, val := RegexCondition(val, opts)
+
+	// Create an array to store the $or conditions
+	orQueries := bson.A{}
+
+	// Iterate over each field and add a regex condition for it
+	for _, field := range fields {
+		orQueries = append(orQueries, bson.D{{Key: field, Value: condition}})
+	}
+
+	return append(filter, bson.E{Key: "$or", Value: orQueries}), val
+}
+
+func AppendFindByTitleFilter(queryParams url.Values, filter bson.D) (bson.D, string) {
+	return AppendRegexOrFilter(filter, []string{"title.en", "title.mm"}, queryParams.Get("title"))
+}
+
+func AppendFindByTitleAndKeyWordsFilter(queryParams url.Values, filter bson.D) (bson.D, string) {
+	return AppendRegexOrFilter(filter, []string{"title.en", "title.mm", "keywords.en", "keywords.mm"}, queryParams.Get("title"))
+}
+
+func AppendFindByName(queryParams url.Values, filter bson.D) (bson.D, string) {
+	return AppendRegexOrFilter(filter, []string{"name.en", "name.mm"}, queryParams.Get("name"))
+}
+
+func AppendFindByNameAndKeyWordsFilter(queryParams url.Values, filter bson.D) (bson.D, string) {
+	return AppendRegexOrFilter(filter, []string{"name.en", "name.mm", "keywords.en", "keywords.mm"}, queryParams.Get("name"))
 }
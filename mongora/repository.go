@@ -0,0 +1,108 @@
+package mongora
+
+import (
+	"context"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Repository : Typed wrapper around a collection that decodes documents straight into T
+type Repository[T any] struct {
+	collection *mongo.Collection
+}
+
+// NewRepository : Create a typed repository for the given collection
+func NewRepository[T any](collection *mongo.Collection) *Repository[T] {
+	return &Repository[T]{collection: collection}
+}
+
+// Collection : Return the underlying mongo collection
+func (r *Repository[T]) Collection() *mongo.Collection {
+	return r.collection
+}
+
+// Insert : Insert a new document and return its _id, a primitive.ObjectID unless T sets its own id type
+func (r *Repository[T]) Insert(ctx context.Context, document *T) (interface{}, error) {
+	ctx, cancel := withTimeout(ctx, r.collection)
+	defer cancel()
+
+	stamped, err := stampInsert(ctx, r.collection, document)
+	if err != nil {
+		return nil, err
+	}
+
+	recordId, err := r.collection.InsertOne(ctx, stamped)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return recordId.InsertedID, nil
+}
+
+// FindByID : Find a document by its hex ObjectID
+func (r *Repository[T]) FindByID(ctx context.Context, id string) (*T, error) {
+	return findById[T](ctx, r.collection, id)
+}
+
+// FindByIdOrSlug : Find a document by ObjectID, falling back to the slug field
+func (r *Repository[T]) FindByIdOrSlug(ctx context.Context, id string) (*T, error) {
+	return findByIdOrSlug[T](ctx, r.collection, id)
+}
+
+// FindOne : Find the first document matching the filter
+func (r *Repository[T]) FindOne(ctx context.Context, filter interface{}) (*T, error) {
+	return findOne[T](ctx, r.collection, filter)
+}
+
+// Find : Find documents using the projection, sort and paging options stored in the context
+func (r *Repository[T]) Find(ctx context.Context, filter interface{}) ([]T, error) {
+	return findMany[T](ctx, r.collection, filter, "")
+}
+
+// FindWithAddonFields : Same as Find but always includes the given addon fields in the projection
+func (r *Repository[T]) FindWithAddonFields(ctx context.Context, filter interface{}, addonFields string) ([]T, error) {
+	return findMany[T](ctx, r.collection, filter, addonFields)
+}
+
+// Update : Apply the update to the first matching document and return it after the update
+func (r *Repository[T]) Update(ctx context.Context, filter interface{}, update interface{}) (*T, error) {
+	ctx, cancel := withTimeout(ctx, r.collection)
+	defer cancel()
+
+	update, err := stampUpdate(ctx, r.collection, update, false)
+	if err != nil {
+		return nil, err
+	}
+
+	var result T
+
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After) // Return the document after update
+	err = r.collection.FindOneAndUpdate(ctx, scopeFilter(ctx, r.collection, filter), update, opts).Decode(&result)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return &result, nil
+}
+
+// Delete : Delete the first matching document (soft-delete collections only mark it), returns false when nothing matched
+func (r *Repository[T]) Delete(ctx context.Context, filter interface{}) (bool, error) {
+	ctx, cancel := withTimeout(ctx, r.collection)
+	defer cancel()
+
+	affected, err := deleteOne(ctx, r.collection, filter)
+	if err != nil {
+		return false, err
+	}
+
+	return affected > 0, nil
+}
+
+// Count : Count the documents matching the filter
+func (r *Repository[T]) Count(ctx context.Context, filter interface{}) (int64, error) {
+	ctx, cancel := withTimeout(ctx, r.collection)
+	defer cancel()
+
+	count, err := r.collection.CountDocuments(ctx, scopeFilter(ctx, r.collection, filter))
+	return count, translateError(err)
+}
@@ -0,0 +1,433 @@
+package mongora
+
+import (
+	"encoding/json"
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+// ResourceOptions : Routing, filtering, validation and hooks for NewResource
+type ResourceOptions struct {
+	// Prefix the handler is mounted under, e.g. "/api/songs". Routes are Prefix and Prefix/{id}.
+	Prefix string
+
+	// FilterFields whitelists the list filters, defaults to the collection's configured FilterFields
+	FilterFields FilterFields
+
+	// AddonFields are always projected on list and get
+	AddonFields string
+
+	// Query sets the page size policy when the handler is not already wrapped in QueryMiddleware
+	Query QueryMiddlewareOptions
+
+	// Model returns a new pointer to the struct request bodies are decoded into and validated with
+	// BodyValidate. When nil, bodies are stored as plain JSON objects without validation.
+	Model func() interface{}
+
+	// Decode sets the body size limit and unknown field policy for create and update
+	Decode DecodeOptions
+
+	// ReadOnly only exposes the list and get routes
+	ReadOnly bool
+
+	Hooks ResourceHooks
+}
+
+// ResourceHooks : Optional callbacks around each route, an error aborts the request and is written as the response
+type ResourceHooks struct {
+	BeforeList   func(r *http.Request, filter bson.D) (bson.D, error)
+	AfterFind    func(r *http.Request, document bson.M) error
+	BeforeCreate func(r *http.Request, document bson.M) error
+	AfterCreate  func(r *http.Request, document bson.M) error
+	BeforeUpdate func(r *http.Request, id string, update bson.M) error
+	AfterUpdate  func(r *http.Request, document bson.M) error
+	BeforeDelete func(r *http.Request, id string) error
+	AfterDelete  func(r *http.Request, id string) error
+}
+
+// Resource : http.Handler exposing CRUD routes for one collection
+type Resource struct {
+	collection *mongo.Collection
+	opts       ResourceOptions
+}
+
+// NewResource : CRUD handler for a collection
+//
+//	GET    {prefix}       list with filters, sort, projection and paging
+//	POST   {prefix}       create
+//	GET    {prefix}/{id}  get by id or slug
+//	PUT    {prefix}/{id}  update every field of the model
+//	PATCH  {prefix}/{id}  update only the fields in the body
+//	DELETE {prefix}/{id}  delete, or soft-delete on soft-delete collections
+func NewResource(collection *mongo.Collection, opts ResourceOptions) *Resource {
+	opts.Prefix = strings.TrimSuffix(opts.Prefix, "/")
+	return &Resource{collection: collection, opts: opts}
+}
+
+func (res *Resource) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path, found := strings.CutPrefix(r.URL.Path, res.opts.Prefix)
+	if !found || (path != "" && !strings.HasPrefix(path, "/")) {
+		WriteError(w, fmt.Errorf("%w: no route for %s", ErrNotFound, r.URL.Path))
+		return
+	}
+	id := strings.Trim(path, "/")
+	if strings.Contains(id, "/") {
+		WriteError(w, fmt.Errorf("%w: no route for %s", ErrNotFound, r.URL.Path))
+		return
+	}
+
+	switch {
+	case id == "" && r.Method == http.MethodGet:
+		res.list(w, r)
+	case id == "" && r.Method == http.MethodPost && !res.opts.ReadOnly:
+		res.create(w, r)
+	case id != "" && r.Method == http.MethodGet:
+		res.get(w, r, id)
+	case id != "" && r.Method == http.MethodPut && !res.opts.ReadOnly:
+		res.update(w, r, id, false)
+	case id != "" && r.Method == http.MethodPatch && !res.opts.ReadOnly:
+		res.update(w, r, id, true)
+	case id != "" && r.Method == http.MethodDelete && !res.opts.ReadOnly:
+		res.delete(w, r, id)
+	default:
+		res.methodNotAllowed(w, id)
+	}
+}
+
+func (res *Resource) list(w http.ResponseWriter, r *http.Request) {
+	filterFields := res.opts.FilterFields
+	if filterFields == nil {
+		filterFields = GetCollectionOptions(res.collection).FilterFields
+	}
+
+	filter, err := ParseFilter(r.URL.Query(), filterFields)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	if res.opts.Hooks.BeforeList != nil {
+		if filter, err = res.opts.Hooks.BeforeList(r, filter); err != nil {
+			WriteError(w, err)
+			return
+		}
+	}
+
+	ctx := r.Context()
+	if _, ok := QueryParamsFromContext(ctx); !ok {
+		params, err := ParseQueryParams(r.URL.Query(), res.opts.Query)
+		if err != nil {
+			WriteError(w, err)
+			return
+		}
+		ctx = WithQueryParams(ctx, params)
+	}
+
+	page, err := FindPageWithAddonFields(ctx, res.collection, filter, res.opts.AddonFields)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	for _, document := range page.Items {
+		if err := res.afterFind(r, document); err != nil {
+			WriteError(w, err)
+			return
+		}
+	}
+
+	WriteJSON(w, http.StatusOK, page)
+}
+
+func (res *Resource) get(w http.ResponseWriter, r *http.Request, id string) {
+	document, err := findByIdOrSlug[bson.M](r.Context(), res.collection, id)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	if err := res.afterFind(r, *document); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, document)
+}
+
+func (res *Resource) create(w http.ResponseWriter, r *http.Request) {
+	document, err := res.decodeBody(r, false)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	if res.opts.Hooks.BeforeCreate != nil {
+		if err := res.opts.Hooks.BeforeCreate(r, document); err != nil {
+			WriteError(w, err)
+			return
+		}
+	}
+
+	recordId, err := InsertOneWithContext(r.Context(), res.collection, document)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	created, err := FindByIdWithContext(r.Context(), res.collection, recordId.Hex())
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	if res.opts.Hooks.AfterCreate != nil {
+		if err := res.opts.Hooks.AfterCreate(r, created); err != nil {
+			WriteError(w, err)
+			return
+		}
+	}
+
+	w.Header().Set("Location", res.opts.Prefix+"/"+recordId.Hex())
+	WriteJSON(w, http.StatusCreated, created)
+}
+
+func (res *Resource) update(w http.ResponseWriter, r *http.Request, id string, partial bool) {
+	filter, err := resourceIdFilter(id)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	document, err := res.decodeBody(r, partial)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	if res.opts.Hooks.BeforeUpdate != nil {
+		if err := res.opts.Hooks.BeforeUpdate(r, id, document); err != nil {
+			WriteError(w, err)
+			return
+		}
+	}
+	if len(document) == 0 {
+		WriteError(w, &ValidationError{Message: "Request Body: no fields to update"})
+		return
+	}
+
+	updated, err := FindOneAndUpdateWithContext(r.Context(), res.collection, filter, bson.M{"$set": document})
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	if res.opts.Hooks.AfterUpdate != nil {
+		if err := res.opts.Hooks.AfterUpdate(r, updated); err != nil {
+			WriteError(w, err)
+			return
+		}
+	}
+
+	WriteJSON(w, http.StatusOK, updated)
+}
+
+func (res *Resource) delete(w http.ResponseWriter, r *http.Request, id string) {
+	filter, err := resourceIdFilter(id)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	if res.opts.Hooks.BeforeDelete != nil {
+		if err := res.opts.Hooks.BeforeDelete(r, id); err != nil {
+			WriteError(w, err)
+			return
+		}
+	}
+
+	ctx, cancel := withTimeout(r.Context(), res.collection)
+	deleted, err := deleteOne(ctx, res.collection, filter)
+	cancel()
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	if deleted == 0 {
+		WriteError(w, ErrNotFound)
+		return
+	}
+
+	if res.opts.Hooks.AfterDelete != nil {
+		if err := res.opts.Hooks.AfterDelete(r, id); err != nil {
+			WriteError(w, err)
+			return
+		}
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (res *Resource) afterFind(r *http.Request, document bson.M) error {
+	if res.opts.Hooks.AfterFind == nil {
+		return nil
+	}
+	return res.opts.Hooks.AfterFind(r, document)
+}
+
+func (res *Resource) methodNotAllowed(w http.ResponseWriter, id string) {
+	allowed := []string{http.MethodGet}
+	if !res.opts.ReadOnly {
+		if id == "" {
+			allowed = append(allowed, http.MethodPost)
+		} else {
+			allowed = append(allowed, http.MethodPut, http.MethodPatch, http.MethodDelete)
+		}
+	}
+
+	w.Header().Set("Allow", strings.Join(allowed, ", "))
+	WriteJSON(w, http.StatusMethodNotAllowed, ErrorResponse{Error: ErrorBody{
+		Status:  http.StatusMethodNotAllowed,
+		Message: http.StatusText(http.StatusMethodNotAllowed),
+	}})
+}
+
+// decodeBody : Decode and validate the body into a document ready to store. Partial bodies are validated
+// only on the fields they contain. The _id, the ProtectedFields and the version field can never be set
+// from a request, they are left to stampInsert and stampUpdate.
+func (res *Resource) decodeBody(r *http.Request, partial bool) (bson.M, error) {
+	raw, err := readJSONBody(r, res.opts.Decode)
+	if err != nil {
+		return nil, err
+	}
+
+	var present map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &present); err != nil {
+		return nil, &ValidationError{Message: "Request Body: expected a JSON object"}
+	}
+
+	// Without a model the JSON object is stored as is
+	if res.opts.Model == nil {
+		var document bson.M
+		if err := json.Unmarshal(raw, &document); err != nil {
+			return nil, &ValidationError{Message: "Request Body: Invalid JSON format"}
+		}
+		return res.withoutProtectedFields(document), nil
+	}
+
+	model := res.opts.Model()
+	trans := requestTranslator(r)
+	if err := decodeJSON(raw, model, res.opts.Decode.AllowUnknownFields, trans); err != nil {
+		return nil, err
+	}
+
+	fields := modelFields(model)
+	if !partial {
+		if err := BodyValidate.Struct(model); err != nil {
+			return nil, validationErrorIn(trans, err)
+		}
+	} else {
+		var names []string
+		for key := range present {
+			if field, ok := fields[key]; ok {
+				names = append(names, field.goName)
+			}
+		}
+		if err := BodyValidate.StructPartial(model, names...); err != nil {
+			return nil, validationErrorIn(trans, err)
+		}
+	}
+
+	if !partial {
+		// Round trip through BSON so the stored values get the model's types and bson names
+		data, err := bson.Marshal(model)
+		if err != nil {
+			return nil, fmt.Errorf("mongora: converting request body: %w", err)
+		}
+		var document bson.M
+		if err := bson.Unmarshal(data, &document); err != nil {
+			return nil, fmt.Errorf("mongora: converting request body: %w", err)
+		}
+		return res.withoutProtectedFields(document), nil
+	}
+
+	// Read the fields straight from the struct, omitempty would drop values such as false or 0
+	modelValue := reflect.Indirect(reflect.ValueOf(model))
+	patch := bson.M{}
+	for key := range present {
+		field, ok := fields[key]
+		if ok {
+			patch[field.bsonName] = modelValue.FieldByName(field.goName).Interface()
+		}
+	}
+	return res.withoutProtectedFields(patch), nil
+}
+
+// withoutProtectedFields : Drop the fields a patch could not write either, using the same rules as ApplyMergePatch
+func (res *Resource) withoutProtectedFields(document bson.M) bson.M {
+	builder := newUpdateBuilder(collectionPatchOptions(res.collection, PatchOptions{}))
+	for field := range document {
+		if builder.checkPath(field) != nil {
+			delete(document, field)
+		}
+	}
+	return document
+}
+
+// modelField : A top level struct field under its JSON and BSON names
+type modelField struct {
+	goName   string
+	bsonName string
+}
+
+// modelFields : Top level fields of the model keyed by their JSON name
+func modelFields(model interface{}) map[string]modelField {
+	fields := map[string]modelField{}
+
+	modelType := reflect.TypeOf(model)
+	for modelType.Kind() == reflect.Pointer {
+		modelType = modelType.Elem()
+	}
+	if modelType.Kind() != reflect.Struct {
+		return fields
+	}
+
+	for i := 0; i < modelType.NumField(); i++ {
+		field := modelType.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		jsonName := tagName(field.Tag.Get("json"), field.Name)
+		if jsonName == "-" {
+			continue
+		}
+		bsonName := tagName(field.Tag.Get("bson"), strings.ToLower(field.Name))
+		fields[jsonName] = modelField{goName: field.Name, bsonName: bsonName}
+	}
+	return fields
+}
+
+func tagName(tag string, fallback string) string {
+	name, _, _ := strings.Cut(tag, ",")
+	if name == "" {
+		return fallback
+	}
+	return name
+}
+
+func resourceIdFilter(id string) (bson.D, error) {
+	objectId, err := StringToObjectId(id)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidID, id)
+	}
+	return bson.D{{Key: "_id", Value: objectId}}, nil
+}
+
+// WriteJSON : Write value as a JSON response with the given status
+func WriteJSON(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(value)
+}
+
+// WriteError : Write err as an ErrorResponse envelope with the status from HTTPStatus
+func WriteError(w http.ResponseWriter, err error) {
+	response := NewErrorResponse(err)
+	WriteJSON(w, response.Error.Status, response)
+}
@@ -0,0 +1,366 @@
+package mongora
+
+import (
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson"
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+	"strings"
+	"unicode"
+)
+
+// DefaultSearchField : Field WithSearchTokens stores tokens in when no field is given
+const DefaultSearchField = "search_tokens"
+
+// Tokenizer : Splits text into search tokens, implementations must be safe for concurrent use
+type Tokenizer interface {
+	Tokenize(text string) []string
+}
+
+// TokenizerFunc : Adapter so a plain function can be used as a Tokenizer
+type TokenizerFunc func(text string) []string
+
+func (f TokenizerFunc) Tokenize(text string) []string {
+	return f(text)
+}
+
+// UnicodeTokenizer : Split into runs of letters, digits and combining marks, also breaking where the
+// script changes so "iPhoneဖုန်း" gives "iPhone" and "ဖုန်း". Text without spaces stays one token per run.
+type UnicodeTokenizer struct{}
+
+func (UnicodeTokenizer) Tokenize(text string) []string {
+	var tokens []string
+	var current []rune
+	currentMyanmar := false
+
+	flush := func() {
+		if len(current) > 0 {
+			tokens = append(tokens, string(current))
+			current = current[:0]
+		}
+	}
+
+	for _, r := range text {
+		if !isTokenRune(r) {
+			flush()
+			continue
+		}
+
+		// Marks always stay with their base character
+		isMark := unicode.Is(unicode.M, r)
+		if !isMark && len(current) > 0 && isMyanmarRune(r) != currentMyanmar {
+			flush()
+		}
+		if len(current) == 0 {
+			currentMyanmar = isMyanmarRune(r)
+		}
+		current = append(current, r)
+	}
+	flush()
+
+	return tokens
+}
+
+// MyanmarSyllableTokenizer : UnicodeTokenizer that further breaks Myanmar runs into syllables,
+// Myanmar script does not separate words with spaces so syllables are the smallest searchable unit
+type MyanmarSyllableTokenizer struct{}
+
+func (MyanmarSyllableTokenizer) Tokenize(text string) []string {
+	var tokens []string
+	for _, word := range (UnicodeTokenizer{}).Tokenize(text) {
+		if isMyanmarRune([]rune(word)[0]) {
+			tokens = append(tokens, breakMyanmarSyllables(word)...)
+		} else {
+			tokens = append(tokens, word)
+		}
+	}
+	return tokens
+}
+
+// SearchTokenizer : Normalise text, split it with Tokenizer and expand every token into edge n-grams
+// so a prefix of any word matches. Tokens are deduplicated and keep their first-seen order.
+type SearchTokenizer struct {
+	// Tokenizer splits the normalised text, defaults to MyanmarSyllableTokenizer
+	Tokenizer Tokenizer
+
+	Lowercase      bool
+	FoldDiacritics bool
+
+	// MinGram and MaxGram bound the edge n-gram length in characters, MaxGram 0 disables n-grams.
+	// Tokens longer than MaxGram are still stored whole so exact matches keep working.
+	MinGram int
+	MaxGram int
+}
+
+// NewSearchTokenizer : Lowercasing, diacritic folding and edge n-grams of 2 to 15 characters
+func NewSearchTokenizer() *SearchTokenizer {
+	return &SearchTokenizer{
+		Tokenizer:      MyanmarSyllableTokenizer{},
+		Lowercase:      true,
+		FoldDiacritics: true,
+		MinGram:        2,
+		MaxGram:        15,
+	}
+}
+
+// Tokenize : Tokens to store on the document, including edge n-grams
+func (t *SearchTokenizer) Tokenize(text string) []string {
+	seen := map[string]bool{}
+	var tokens []string
+	add := func(token string) {
+		if token != "" && !seen[token] {
+			seen[token] = true
+			tokens = append(tokens, token)
+		}
+	}
+
+	for _, word := range t.QueryTokens(text) {
+		for _, gram := range edgeNGrams(word, t.MinGram, t.MaxGram) {
+			add(gram)
+		}
+		add(word)
+	}
+	return tokens
+}
+
+// QueryTokens : Normalised tokens without n-grams, what a search query should be matched with
+func (t *SearchTokenizer) QueryTokens(text string) []string {
+	text = t.normalize(text)
+
+	tokenizer := t.Tokenizer
+	if tokenizer == nil {
+		tokenizer = MyanmarSyllableTokenizer{}
+	}
+
+	seen := map[string]bool{}
+	var tokens []string
+	for _, token := range tokenizer.Tokenize(text) {
+		if !seen[token] {
+			seen[token] = true
+			tokens = append(tokens, token)
+		}
+	}
+	return tokens
+}
+
+func (t *SearchTokenizer) normalize(text string) string {
+	if t.FoldDiacritics {
+		text = foldDiacritics(text)
+	}
+	if t.Lowercase {
+		text = strings.ToLower(text)
+	}
+	return norm.NFC.String(text)
+}
+
+// WithSearchTokens : Tokenize the string values at the source fields and store them in searchField.
+// A source field holding a sub-document, such as a bilingual title, contributes every string inside it.
+func WithSearchTokens(document interface{}, searchField string, tokenizer Tokenizer, sourceFields ...string) (bson.D, error) {
+	doc, err := toBsonD(document)
+	if err != nil {
+		return nil, err
+	}
+	if searchField == "" {
+		searchField = DefaultSearchField
+	}
+	if tokenizer == nil {
+		tokenizer = NewSearchTokenizer()
+	}
+
+	var texts []string
+	for _, field := range sourceFields {
+		value, found := lookupPath(doc, field)
+		if found {
+			texts = collectStrings(value, texts)
+		}
+	}
+
+	tokens := tokenizer.Tokenize(strings.Join(texts, " "))
+	if tokens == nil {
+		tokens = []string{}
+	}
+	return setField(doc, searchField, tokens), nil
+}
+
+// SearchTokensFilter : Condition requiring every query token in the search field, e.g. {search_tokens: {$all: [...]}}
+func SearchTokensFilter(searchField string, query string, tokenizer *SearchTokenizer) (bson.E, error) {
+	if searchField == "" {
+		searchField = DefaultSearchField
+	}
+	if tokenizer == nil {
+		tokenizer = NewSearchTokenizer()
+	}
+
+	tokens := tokenizer.QueryTokens(query)
+	if len(tokens) == 0 {
+		return bson.E{}, &ValidationError{Message: fmt.Sprintf("Search query '%s' has no searchable characters", query)}
+	}
+
+	// Words longer than the n-grams were stored whole, so a long query word is cut back to its prefix
+	if tokenizer.MaxGram > 0 {
+		for i, token := range tokens {
+			if len([]rune(token)) <= tokenizer.MaxGram {
+				continue
+			}
+			if grams := edgeNGrams(token, tokenizer.MinGram, tokenizer.MaxGram); len(grams) > 0 {
+				tokens[i] = grams[len(grams)-1]
+			}
+		}
+	}
+
+	return bson.E{Key: searchField, Value: bson.D{{Key: "$all", Value: tokens}}}, nil
+}
+
+// edgeNGrams : Prefixes of the token from minGram to maxGram characters, never cutting a base
+// character from its combining marks
+func edgeNGrams(token string, minGram int, maxGram int) []string {
+	if maxGram <= 0 {
+		return nil
+	}
+	minGram = max(minGram, 1)
+
+	var grams []string
+	characters := 0
+	letters := []rune(token)
+	for i, r := range letters {
+		if !unicode.Is(unicode.M, r) {
+			characters++
+		}
+
+		// Only cut where the next rune starts a new character
+		if i+1 < len(letters) && unicode.Is(unicode.M, letters[i+1]) {
+			continue
+		}
+		if characters > maxGram {
+			break
+		}
+		if characters >= minGram && i+1 < len(letters) {
+			grams = append(grams, string(letters[:i+1]))
+		}
+	}
+	return grams
+}
+
+// foldableMarks : Latin, Greek and Cyrillic combining diacritics. Myanmar vowel signs and medials are
+// marks too but carry meaning, so they are never removed.
+var foldableMarks = &unicode.RangeTable{
+	R16: []unicode.Range16{
+		{Lo: 0x0300, Hi: 0x036F, Stride: 1},
+		{Lo: 0x1AB0, Hi: 0x1AFF, Stride: 1},
+		{Lo: 0x1DC0, Hi: 0x1DFF, Stride: 1},
+		{Lo: 0x20D0, Hi: 0x20FF, Stride: 1},
+		{Lo: 0xFE20, Hi: 0xFE2F, Stride: 1},
+	},
+}
+
+// foldDiacritics : Decompose, drop the foldable combining marks and recompose, "Café" becomes "Cafe"
+func foldDiacritics(text string) string {
+	folder := transform.Chain(norm.NFD, runes.Remove(runes.In(foldableMarks)), norm.NFC)
+	folded, _, err := transform.String(folder, text)
+	if err != nil {
+		return text
+	}
+	return folded
+}
+
+func isTokenRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsNumber(r) || unicode.Is(unicode.M, r)
+}
+
+func isMyanmarRune(r rune) bool {
+	return unicode.Is(unicode.Myanmar, r)
+}
+
+// breakMyanmarSyllables : Rule based syllable segmentation. A syllable starts at a consonant unless it is
+// stacked under a virama or killed by an asat, and at every independent vowel, digit or symbol.
+func breakMyanmarSyllables(word string) []string {
+	letters := []rune(word)
+
+	var syllables []string
+	start := 0
+	for i := 1; i < len(letters); i++ {
+		if isMyanmarSyllableStart(letters, i) {
+			syllables = append(syllables, string(letters[start:i]))
+			start = i
+		}
+	}
+	return append(syllables, string(letters[start:]))
+}
+
+const (
+	myanmarVirama = '္'
+	myanmarAsat   = '်'
+)
+
+func isMyanmarSyllableStart(letters []rune, i int) bool {
+	r := letters[i]
+
+	switch {
+	// Consonants
+	case r >= 'က' && r <= 'အ':
+		if letters[i-1] == myanmarVirama {
+			return false
+		}
+		if i+1 < len(letters) && (letters[i+1] == myanmarAsat || letters[i+1] == myanmarVirama) {
+			return false
+		}
+		return true
+
+	// Independent vowels, digits and the standalone symbols
+	case r >= 'ဣ' && r <= 'ဪ', r == 'ဿ', r >= '၀' && r <= '၉', r >= '၌' && r <= '၏':
+		return true
+	}
+
+	return false
+}
+
+// lookupPath : Value at a dotted path, walking nested documents
+func lookupPath(doc bson.D, path string) (interface{}, bool) {
+	var current interface{} = doc
+	for _, part := range strings.Split(path, ".") {
+		switch value := current.(type) {
+		case bson.D:
+			found := false
+			for _, elem := range value {
+				if elem.Key == part {
+					current, found = elem.Value, true
+					break
+				}
+			}
+			if !found {
+				return nil, false
+			}
+		case bson.M:
+			next, found := value[part]
+			if !found {
+				return nil, false
+			}
+			current = next
+		default:
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+// collectStrings : Append every string in the value, descending into documents and arrays
+func collectStrings(value interface{}, texts []string) []string {
+	switch v := value.(type) {
+	case string:
+		return append(texts, v)
+	case bson.D:
+		for _, elem := range v {
+			texts = collectStrings(elem.Value, texts)
+		}
+	case bson.M:
+		for _, elem := range v {
+			texts = collectStrings(elem, texts)
+		}
+	case bson.A:
+		for _, elem := range v {
+			texts = collectStrings(elem, texts)
+		}
+	}
+	return texts
+}
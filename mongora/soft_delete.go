@@ -0,0 +1,135 @@
+package mongora
+
+import (
+	"context"
+	goNest "github.com/thetnswe/mongora/go_nest"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"time"
+)
+
+// Fields written by a soft delete
+const (
+	DeletedAtField = "deleted_at"
+	DeletedByField = "deleted_by"
+)
+
+// deletedScope : Which documents reads see on a soft-delete collection
+type deletedScope int
+
+const (
+	excludeDeleted deletedScope = iota
+	includeDeleted
+	onlyDeleted
+)
+
+// WithDeleted : Reads made with the returned context also see soft-deleted documents
+func WithDeleted(ctx context.Context) context.Context {
+	return context.WithValue(ctx, deletedScopeKey, includeDeleted)
+}
+
+// OnlyDeleted : Reads made with the returned context see nothing but soft-deleted documents
+func OnlyDeleted(ctx context.Context) context.Context {
+	return context.WithValue(ctx, deletedScopeKey, onlyDeleted)
+}
+
+// Restore : Undo the soft delete of every matching document
+func Restore(ctx context.Context, collection *mongo.Collection, filter interface{}) (int64, error) {
+	ctx, cancel := withTimeout(ctx, collection)
+	defer cancel()
+
+	filter = andFilter(filter, bson.D{{Key: DeletedAtField, Value: bson.D{{Key: "$ne", Value: nil}}}})
+	update := bson.D{{Key: "$unset", Value: bson.D{
+		{Key: DeletedAtField, Value: ""},
+		{Key: DeletedByField, Value: ""},
+	}}}
+
+	result, err := collection.UpdateMany(ctx, filter, update)
+	if err != nil {
+		return 0, translateError(err)
+	}
+	return result.ModifiedCount, nil
+}
+
+// Purge : Permanently remove documents that were soft-deleted more than olderThan ago
+func Purge(ctx context.Context, collection *mongo.Collection, olderThan time.Duration) (int64, error) {
+	ctx, cancel := withTimeout(ctx, collection)
+	defer cancel()
+
+	cutoff := primitive.NewDateTimeFromTime(time.Now().Add(-olderThan))
+	filter := bson.D{{Key: DeletedAtField, Value: bson.D{{Key: "$lte", Value: cutoff}}}}
+
+	result, err := collection.DeleteMany(ctx, filter)
+	if err != nil {
+		return 0, translateError(err)
+	}
+	return result.DeletedCount, nil
+}
+
+// Restore : Undo the soft delete of every matching document
+func (r *Repository[T]) Restore(ctx context.Context, filter interface{}) (int64, error) {
+	return Restore(ctx, r.collection, filter)
+}
+
+// Purge : Permanently remove documents that were soft-deleted more than olderThan ago
+func (r *Repository[T]) Purge(ctx context.Context, olderThan time.Duration) (int64, error) {
+	return Purge(ctx, r.collection, olderThan)
+}
+
+// scopeFilter : Hide soft-deleted documents from reads unless the context asks for them
+func scopeFilter(ctx context.Context, collection *mongo.Collection, filter interface{}) interface{} {
+	if !GetCollectionOptions(collection).SoftDelete {
+		return filter
+	}
+
+	scope, _ := ctx.Value(deletedScopeKey).(deletedScope)
+	switch scope {
+	case includeDeleted:
+		return filter
+	case onlyDeleted:
+		return andFilter(filter, bson.D{{Key: DeletedAtField, Value: bson.D{{Key: "$ne", Value: nil}}}})
+	default:
+		// Matches both a missing and a null deleted_at
+		return andFilter(filter, bson.D{{Key: DeletedAtField, Value: nil}})
+	}
+}
+
+// softDeleteUpdate : The update that marks a document as deleted, deleted_by is only set when an actor is known
+func softDeleteUpdate(ctx context.Context) bson.D {
+	set := bson.D{{Key: DeletedAtField, Value: goNest.GetMongoTime()}}
+	if actor, ok := ActorFromContext(ctx); ok {
+		set = append(set, bson.E{Key: DeletedByField, Value: actor})
+	}
+
+	return bson.D{{Key: "$set", Value: set}}
+}
+
+// andFilter : Combine an arbitrary caller filter with an extra condition
+func andFilter(filter interface{}, condition bson.D) interface{} {
+	if filter == nil {
+		return condition
+	}
+	if filterD, ok := filter.(bson.D); ok && len(filterD) == 0 {
+		return condition
+	}
+
+	return bson.D{{Key: "$and", Value: bson.A{filter, condition}}}
+}
+
+// deleteOne : Soft-delete or remove the first matching document, returns how many documents were affected
+func deleteOne(ctx context.Context, collection *mongo.Collection, filter interface{}) (int64, error) {
+	if GetCollectionOptions(collection).SoftDelete {
+		result, err := collection.UpdateOne(ctx, scopeFilter(ctx, collection, filter), softDeleteUpdate(ctx))
+		if err != nil {
+			return 0, translateError(err)
+		}
+		return result.ModifiedCount, nil
+	}
+
+	result, err := collection.DeleteOne(ctx, filter)
+	if err != nil {
+		return 0, translateError(err)
+	}
+	return result.DeletedCount, nil
+}
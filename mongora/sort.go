@@ -0,0 +1,98 @@
+package mongora
+
+import (
+	"context"
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson"
+	"strings"
+)
+
+// ParseSort : Parse a sort expression such as "-created_at,title.en,+priority" into an ordered bson.D.
+// Fields must appear in the allowlist unless it is empty, and _id is appended as a stable tiebreaker.
+func ParseSort(expression string, allowed []string) (bson.D, error) {
+	sortOrder := bson.D{}
+	seen := map[string]bool{}
+	var fieldErrors []FieldError
+
+	for _, part := range strings.Split(expression, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		direction := 1
+		if strings.HasPrefix(part, "-") {
+			direction = -1
+			part = part[1:]
+		} else if strings.HasPrefix(part, "+") {
+			part = part[1:]
+		}
+
+		if part == "" {
+			fieldErrors = append(fieldErrors, FieldError{Field: "sort", Message: "empty sort field"})
+			continue
+		}
+		if !isSortFieldAllowed(part, allowed) {
+			fieldErrors = append(fieldErrors, FieldError{Field: part, Tag: "sort", Message: fmt.Sprintf("sorting on '%s' is not allowed", part)})
+			continue
+		}
+		if seen[part] {
+			continue
+		}
+
+		seen[part] = true
+		sortOrder = append(sortOrder, bson.E{Key: part, Value: direction})
+	}
+
+	if len(fieldErrors) > 0 {
+		return nil, &ValidationError{Message: "Invalid sort", Fields: fieldErrors}
+	}
+
+	return appendSortTiebreaker(sortOrder), nil
+}
+
+// appendSortTiebreaker : Sorting on non-unique keys is not deterministic, so always finish with _id
+func appendSortTiebreaker(sortOrder bson.D) bson.D {
+	if len(sortOrder) == 0 {
+		return sortOrder
+	}
+
+	for _, elem := range sortOrder {
+		if elem.Key == "_id" {
+			return sortOrder
+		}
+	}
+
+	return append(sortOrder, bson.E{Key: "_id", Value: 1})
+}
+
+func isSortFieldAllowed(field string, allowed []string) bool {
+	if len(allowed) == 0 || field == "_id" {
+		return true
+	}
+
+	for _, allowedField := range allowed {
+		if allowedField == field {
+			return true
+		}
+	}
+	return false
+}
+
+// sortFromContext : Build the sort from the `sort` context key, falling back to the legacy order_by/order pair
+func sortFromContext(ctx context.Context, allowed []string) (bson.D, error) {
+	if expression := contextString(ctx, "sort"); expression != "" {
+		return ParseSort(expression, allowed)
+	}
+
+	orderBy := contextString(ctx, "order_by")
+	order := contextString(ctx, "order")
+	if orderBy == "" || order == "" {
+		return bson.D{}, nil
+	}
+
+	if order == "desc" {
+		return ParseSort("-"+orderBy, allowed)
+	}
+	return ParseSort(orderBy, allowed)
+}
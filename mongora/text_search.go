@@ -0,0 +1,148 @@
+package mongora
+
+import (
+	"context"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// TextScoreField : Field the relevance score is projected into by the ranked search helpers
+const TextScoreField = "score"
+
+// BilingualLanguages : Language suffixes of the bilingual fields, e.g. title.en and title.mm
+var BilingualLanguages = []string{"en", "mm"}
+
+// TextIndexBuilder : Fluent builder for a weighted text index, Spec feeds EnsureIndexes
+type TextIndexBuilder struct {
+	name             string
+	fields           []string
+	weights          map[string]int32
+	defaultLanguage  string
+	languageOverride string
+}
+
+// NewTextIndex : Start a text index, a collection can only have one so the name is usually the collection's
+func NewTextIndex(name string) *TextIndexBuilder {
+	return &TextIndexBuilder{name: name, weights: map[string]int32{}}
+}
+
+// Field : Index a field with the given weight, weights below 1 count as 1
+func (b *TextIndexBuilder) Field(field string, weight int32) *TextIndexBuilder {
+	if _, exists := b.weights[field]; !exists {
+		b.fields = append(b.fields, field)
+	}
+	b.weights[field] = max(weight, 1)
+	return b
+}
+
+// Bilingual : Index every language variant of a field, e.g. "title" adds title.en and title.mm
+func (b *TextIndexBuilder) Bilingual(field string, weight int32) *TextIndexBuilder {
+	for _, language := range BilingualLanguages {
+		b.Field(field+"."+language, weight)
+	}
+	return b
+}
+
+// DefaultLanguage : Stemming and stop words used when a document names no language, e.g. "english" or "none"
+func (b *TextIndexBuilder) DefaultLanguage(language string) *TextIndexBuilder {
+	b.defaultLanguage = language
+	return b
+}
+
+// LanguageOverride : Document field that holds a per-document language, defaults to "language"
+func (b *TextIndexBuilder) LanguageOverride(field string) *TextIndexBuilder {
+	b.languageOverride = field
+	return b
+}
+
+// Spec : The index declaration to pass to EnsureIndexes
+func (b *TextIndexBuilder) Spec() IndexSpec {
+	spec := IndexSpec{
+		Name:             b.name,
+		Weights:          map[string]int32{},
+		DefaultLanguage:  b.defaultLanguage,
+		LanguageOverride: b.languageOverride,
+	}
+	for _, field := range b.fields {
+		spec.Keys = append(spec.Keys, bson.E{Key: field, Value: "text"})
+		spec.Weights[field] = b.weights[field]
+	}
+	return spec
+}
+
+// TextSearchOptions : Options of the $text operator, an empty Language uses the index default
+type TextSearchOptions struct {
+	Language           string
+	CaseSensitive      bool
+	DiacriticSensitive bool
+}
+
+// TextFilter : Build the $text condition for a search string
+func TextFilter(search string, opts ...TextSearchOptions) bson.E {
+	text := bson.D{{Key: "$search", Value: search}}
+	if len(opts) > 0 {
+		if opts[0].Language != "" {
+			text = append(text, bson.E{Key: "$language", Value: opts[0].Language})
+		}
+		if opts[0].CaseSensitive {
+			text = append(text, bson.E{Key: "$caseSensitive", Value: true})
+		}
+		if opts[0].DiacriticSensitive {
+			text = append(text, bson.E{Key: "$diacriticSensitive", Value: true})
+		}
+	}
+	return bson.E{Key: "$text", Value: text}
+}
+
+// TextSearch : Find documents matching the search string, best matches first with the score in TextScoreField.
+// Projection and paging come from the context like Find, a context sort only breaks ties between equal scores.
+func TextSearch(ctx context.Context, collection *mongo.Collection, filter bson.D, search string, opts ...TextSearchOptions) ([]bson.M, error) {
+	return textSearch[bson.M](ctx, collection, filter, search, opts...)
+}
+
+// TextSearch : Typed variant of TextSearch
+func (r *Repository[T]) TextSearch(ctx context.Context, filter bson.D, search string, opts ...TextSearchOptions) ([]T, error) {
+	return textSearch[T](ctx, r.collection, filter, search, opts...)
+}
+
+// FindRanked : Find with a filter that already contains $text, e.g. from AppendTextSearchTitleFilter, ranked by score
+func FindRanked(ctx context.Context, collection *mongo.Collection, filter interface{}) ([]bson.M, error) {
+	return findRanked[bson.M](ctx, collection, filter)
+}
+
+// FindRanked : Typed variant of FindRanked
+func (r *Repository[T]) FindRanked(ctx context.Context, filter interface{}) ([]T, error) {
+	return findRanked[T](ctx, r.collection, filter)
+}
+
+func textSearch[T any](ctx context.Context, collection *mongo.Collection, filter bson.D, search string, opts ...TextSearchOptions) ([]T, error) {
+	textFilter := append(bson.D{}, filter...)
+	textFilter = append(textFilter, TextFilter(search, opts...))
+	return findRanked[T](ctx, collection, textFilter)
+}
+
+func findRanked[T any](ctx context.Context, collection *mongo.Collection, filter interface{}) ([]T, error) {
+	ctx, cancel := withTimeout(ctx, collection)
+	defer cancel()
+
+	opts, err := buildOptionsForQuery(ctx, collection, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return runFind[T](ctx, collection, filter, withTextScore(opts))
+}
+
+// withTextScore : Project the text score and make it the primary sort key ahead of any requested sort
+func withTextScore(opts *options.FindOptions) *options.FindOptions {
+	score := bson.E{Key: TextScoreField, Value: bson.D{{Key: "$meta", Value: "textScore"}}}
+
+	projection, _ := opts.Projection.(bson.D)
+	opts.SetProjection(append(append(bson.D{}, projection...), score))
+
+	sortOrder, _ := opts.Sort.(bson.D)
+	opts.SetSort(append(bson.D{score}, sortOrder...))
+
+	return opts
+}
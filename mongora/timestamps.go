@@ -0,0 +1,278 @@
+package mongora
+
+import (
+	"context"
+	"fmt"
+	goNest "github.com/thetnswe/mongora/go_nest"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"strings"
+)
+
+// Fields stamped on collections configured with Timestamps and AuditFields
+const (
+	CreatedAtField = "created_at"
+	UpdatedAtField = "updated_at"
+	CreatedByField = "created_by"
+	UpdatedByField = "updated_by"
+)
+
+// UpsertOneWithContext : Update the first matching record or insert it, returning the document after the write
+func UpsertOneWithContext(ctx context.Context, collection *mongo.Collection, filter interface{}, update interface{}) (bson.M, error) {
+	ctx, cancel := withTimeout(ctx, collection)
+	defer cancel()
+
+	update, err := stampUpdate(ctx, collection, update, true)
+	if err != nil {
+		return nil, err
+	}
+
+	var result bson.M
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After).SetUpsert(true)
+	err = collection.FindOneAndUpdate(ctx, scopeFilter(ctx, collection, filter), update, opts).Decode(&result)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return result, nil
+}
+
+// Upsert : Update the first matching document or insert it, returning the document after the write
+func (r *Repository[T]) Upsert(ctx context.Context, filter interface{}, update interface{}) (*T, error) {
+	ctx, cancel := withTimeout(ctx, r.collection)
+	defer cancel()
+
+	update, err := stampUpdate(ctx, r.collection, update, true)
+	if err != nil {
+		return nil, err
+	}
+
+	var result T
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After).SetUpsert(true)
+	err = r.collection.FindOneAndUpdate(ctx, scopeFilter(ctx, r.collection, filter), update, opts).Decode(&result)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return &result, nil
+}
+
+// stampInsert : Add created_at/updated_at (and created_by/updated_by) to a document about to be inserted.
+// A created_at already set by the caller is kept, so imports can carry their original timestamps.
+func stampInsert(ctx context.Context, collection *mongo.Collection, document interface{}) (interface{}, error) {
+	collectionOpts := GetCollectionOptions(collection)
+	if !collectionOpts.Timestamps && !collectionOpts.AuditFields && collectionOpts.VersionField == "" {
+		return document, nil
+	}
+
+	doc, err := toBsonD(document)
+	if err != nil {
+		return nil, err
+	}
+
+	now := goNest.GetMongoTime()
+	if collectionOpts.Timestamps {
+		if !hasTimestamp(doc, CreatedAtField) {
+			doc = setField(doc, CreatedAtField, now)
+		}
+		doc = setField(doc, UpdatedAtField, now)
+	}
+
+	if actor, ok := ActorFromContext(ctx); ok && collectionOpts.AuditFields {
+		doc = setField(doc, CreatedByField, actor)
+		doc = setField(doc, UpdatedByField, actor)
+	}
+
+	// Versioned documents start at 1 unless the caller supplied a version
+	if collectionOpts.VersionField != "" && !hasField(doc, collectionOpts.VersionField) {
+		doc = setField(doc, collectionOpts.VersionField, int64(1))
+	}
+
+	return doc, nil
+}
+
+// stampUpdate : Add updated_at to $set, the version bump to $inc and, for upserts, created_at to $setOnInsert
+func stampUpdate(ctx context.Context, collection *mongo.Collection, update interface{}, upsert bool) (interface{}, error) {
+	collectionOpts := GetCollectionOptions(collection)
+	if !collectionOpts.Timestamps && !collectionOpts.AuditFields && collectionOpts.VersionField == "" {
+		return update, nil
+	}
+
+	actor, hasActor := ActorFromContext(ctx)
+	hasActor = hasActor && collectionOpts.AuditFields
+
+	// Aggregation pipeline updates get a trailing $set stage
+	switch pipeline := update.(type) {
+	case mongo.Pipeline:
+		return append(pipeline, pipelineStampStage(collectionOpts, actor, hasActor)), nil
+	case []bson.D:
+		return append(pipeline, pipelineStampStage(collectionOpts, actor, hasActor)), nil
+	case bson.A:
+		return append(pipeline, pipelineStampStage(collectionOpts, actor, hasActor)), nil
+	}
+
+	doc, err := toBsonD(update)
+	if err != nil {
+		return nil, err
+	}
+
+	now := goNest.GetMongoTime()
+	set := bson.D{}
+	setOnInsert := bson.D{}
+	if collectionOpts.Timestamps {
+		set = append(set, bson.E{Key: UpdatedAtField, Value: now})
+		setOnInsert = append(setOnInsert, bson.E{Key: CreatedAtField, Value: now})
+	}
+	if hasActor {
+		set = append(set, bson.E{Key: UpdatedByField, Value: actor})
+		setOnInsert = append(setOnInsert, bson.E{Key: CreatedByField, Value: actor})
+	}
+
+	doc, err = mergeOperator(doc, "$set", set)
+	if err != nil {
+		return nil, err
+	}
+	if collectionOpts.VersionField != "" {
+		doc, err = mergeOperator(doc, "$inc", bson.D{{Key: collectionOpts.VersionField, Value: int64(1)}})
+		if err != nil {
+			return nil, err
+		}
+	}
+	if upsert {
+		doc, err = mergeOperator(doc, "$setOnInsert", setOnInsert)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return doc, nil
+}
+
+func pipelineStampStage(collectionOpts CollectionOptions, actor interface{}, hasActor bool) bson.D {
+	set := bson.D{}
+	if collectionOpts.Timestamps {
+		set = append(set, bson.E{Key: UpdatedAtField, Value: "$$NOW"})
+	}
+	if hasActor {
+		set = append(set, bson.E{Key: UpdatedByField, Value: bson.D{{Key: "$literal", Value: actor}}})
+	}
+	if versionField := collectionOpts.VersionField; versionField != "" {
+		set = append(set, bson.E{Key: versionField, Value: bson.D{{Key: "$add", Value: bson.A{
+			bson.D{{Key: "$ifNull", Value: bson.A{"$" + versionField, 0}}}, 1,
+		}}}})
+	}
+	return bson.D{{Key: "$set", Value: set}}
+}
+
+// mergeOperator : Add fields to an update operator, skipping any path the caller's update already touches
+func mergeOperator(update bson.D, operator string, fields bson.D) (bson.D, error) {
+	if len(fields) == 0 {
+		return update, nil
+	}
+
+	operatorIndex := -1
+	for i, elem := range update {
+		if !strings.HasPrefix(elem.Key, "$") {
+			return nil, fmt.Errorf("mongora: update document must only contain operators, got '%s'", elem.Key)
+		}
+		if elem.Key == operator {
+			operatorIndex = i
+		}
+	}
+
+	var current bson.D
+	if operatorIndex >= 0 {
+		var err error
+		if current, err = toBsonD(update[operatorIndex].Value); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, field := range fields {
+		if !updateTouchesField(update, field.Key) {
+			current = append(current, field)
+		}
+	}
+
+	if operatorIndex >= 0 {
+		update[operatorIndex].Value = current
+	} else if len(current) > 0 {
+		update = append(update, bson.E{Key: operator, Value: current})
+	}
+	return update, nil
+}
+
+// updateTouchesField : Whether any operator already writes the field, adding it again would be a path conflict
+func updateTouchesField(update bson.D, field string) bool {
+	for _, elem := range update {
+		fields, err := toBsonD(elem.Value)
+		if err != nil {
+			continue
+		}
+		for _, existing := range fields {
+			if existing.Key == field || strings.HasPrefix(existing.Key, field+".") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func hasTimestamp(doc bson.D, field string) bool {
+	for _, elem := range doc {
+		if elem.Key != field {
+			continue
+		}
+
+		switch value := elem.Value.(type) {
+		case nil:
+			return false
+		case primitive.DateTime:
+			return !value.Time().IsZero() && value != 0
+		default:
+			return true
+		}
+	}
+	return false
+}
+
+func hasField(doc bson.D, field string) bool {
+	for _, elem := range doc {
+		if elem.Key == field {
+			return true
+		}
+	}
+	return false
+}
+
+// setField : Replace the top level field or append it
+func setField(doc bson.D, field string, value interface{}) bson.D {
+	for i, elem := range doc {
+		if elem.Key == field {
+			doc[i].Value = value
+			return doc
+		}
+	}
+	return append(doc, bson.E{Key: field, Value: value})
+}
+
+// toBsonD : Round trip any document (struct, map, bson.M) through BSON so it can be edited in order.
+// bson.D values are copied so the caller's slice is never modified.
+func toBsonD(document interface{}) (bson.D, error) {
+	if doc, ok := document.(bson.D); ok {
+		return append(bson.D{}, doc...), nil
+	}
+
+	data, err := bson.Marshal(document)
+	if err != nil {
+		return nil, fmt.Errorf("mongora: converting document: %w", err)
+	}
+
+	var doc bson.D
+	if err := bson.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("mongora: converting document: %w", err)
+	}
+	return doc, nil
+}
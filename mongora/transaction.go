@@ -0,0 +1,115 @@
+package mongora
+
+import (
+	"context"
+	"errors"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readconcern"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+	"go.mongodb.org/mongo-driver/mongo/writeconcern"
+	"time"
+)
+
+// TransactionOptions : Concerns and retry budget for WithTransaction, zero values use the defaults
+type TransactionOptions struct {
+	ReadConcern    *readconcern.ReadConcern
+	WriteConcern   *writeconcern.WriteConcern
+	ReadPreference *readpref.ReadPref
+
+	// MaxRetryTime bounds how long transient failures are retried, defaults to 120 seconds
+	MaxRetryTime time.Duration
+}
+
+var defaultTransactionRetryTime = 120 * time.Second
+
+// Error labels the server attaches to retryable transaction failures
+const (
+	transientTransactionError      = "TransientTransactionError"
+	unknownTransactionCommitResult = "UnknownTransactionCommitResult"
+)
+
+// WithTransaction : Run fn inside a transaction, retrying the whole transaction on TransientTransactionError
+// and the commit on UnknownTransactionCommitResult until MaxRetryTime elapses.
+// Pass sessCtx to the *WithContext helpers or a Repository so their operations join the transaction.
+func WithTransaction(ctx context.Context, client *mongo.Client, fn func(sessCtx mongo.SessionContext) error, opts ...TransactionOptions) error {
+	var txnOpts TransactionOptions
+	if len(opts) > 0 {
+		txnOpts = opts[0]
+	}
+
+	maxRetryTime := txnOpts.MaxRetryTime
+	if maxRetryTime <= 0 {
+		maxRetryTime = defaultTransactionRetryTime
+	}
+
+	sessionOpts := options.Transaction()
+	if txnOpts.ReadConcern != nil {
+		sessionOpts.SetReadConcern(txnOpts.ReadConcern)
+	}
+	if txnOpts.WriteConcern != nil {
+		sessionOpts.SetWriteConcern(txnOpts.WriteConcern)
+	}
+	if txnOpts.ReadPreference != nil {
+		sessionOpts.SetReadPreference(txnOpts.ReadPreference)
+	}
+
+	session, err := client.StartSession()
+	if err != nil {
+		return translateError(err)
+	}
+	defer session.EndSession(context.Background())
+
+	deadline := time.Now().Add(maxRetryTime)
+	for {
+		err = mongo.WithSession(ctx, session, func(sessCtx mongo.SessionContext) error {
+			if err := session.StartTransaction(sessionOpts); err != nil {
+				return err
+			}
+
+			if err := fn(sessCtx); err != nil {
+				// Abort on a fresh context so a cancelled caller still releases the transaction
+				_ = session.AbortTransaction(context.Background())
+				return err
+			}
+
+			return commitWithRetry(sessCtx, session, deadline)
+		})
+
+		if err == nil {
+			return nil
+		}
+		if hasErrorLabel(err, transientTransactionError) && time.Now().Before(deadline) && ctx.Err() == nil {
+			continue
+		}
+
+		return translateError(err)
+	}
+}
+
+// commitWithRetry : The commit may have been applied when the result is unknown, committing again is safe
+func commitWithRetry(sessCtx mongo.SessionContext, session mongo.Session, deadline time.Time) error {
+	for {
+		err := session.CommitTransaction(sessCtx)
+		if err == nil {
+			return nil
+		}
+
+		if hasErrorLabel(err, unknownTransactionCommitResult) && !isMaxTimeMSExpired(err) &&
+			time.Now().Before(deadline) && sessCtx.Err() == nil {
+			continue
+		}
+		return err
+	}
+}
+
+func hasErrorLabel(err error, label string) bool {
+	var labeledError mongo.LabeledError
+	return errors.As(err, &labeledError) && labeledError.HasErrorLabel(label)
+}
+
+// isMaxTimeMSExpired : Retrying a commit that ran out of maxTimeMS would only fail again
+func isMaxTimeMSExpired(err error) bool {
+	var serverError mongo.ServerError
+	return errors.As(err, &serverError) && serverError.HasErrorCode(50)
+}
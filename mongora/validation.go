@@ -0,0 +1,217 @@
+package mongora
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// DecodeOptions : Limits for DecodeAndValidate, zero values use the defaults
+type DecodeOptions struct {
+	// MaxBytes caps the body size, defaults to 1 MiB
+	MaxBytes int64
+
+	// AllowUnknownFields accepts JSON keys that have no matching struct field
+	AllowUnknownFields bool
+
+	// AllowAnyContentType skips the application/json Content-Type check
+	AllowAnyContentType bool
+}
+
+var defaultMaxBodyBytes int64 = 1 << 20
+
+// slugPattern : Lowercase words of letters and digits joined by single hyphens
+var slugPattern = regexp.MustCompile(`^[a-z0-9]+(?:-[a-z0-9]+)*$`)
+
+func init() {
+	// Report fields by their JSON names, the names clients actually sent
+	BodyValidate.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name := tagName(field.Tag.Get("json"), field.Name)
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+
+	_ = BodyValidate.RegisterValidation("objectid", validateObjectId)
+	_ = BodyValidate.RegisterValidation("slug", validateSlug)
+	_ = BodyValidate.RegisterValidation("bilingual", validateBilingual)
+}
+
+// RegisterValidator : Add a custom validation tag to BodyValidate, e.g. `validate:"isbn"`
+func RegisterValidator(tag string, fn validator.Func) error {
+	return BodyValidate.RegisterValidation(tag, fn)
+}
+
+// DecodeAndValidate : Decode a JSON request body into T and validate it with BodyValidate.
+// Unknown fields, a non-JSON Content-Type and bodies over the size limit are rejected.
+func DecodeAndValidate[T any](r *http.Request, opts ...DecodeOptions) (*T, error) {
+	var model T
+	if err := decodeAndValidate(r, &model, opts...); err != nil {
+		return nil, err
+	}
+	return &model, nil
+}
+
+func decodeAndValidate(r *http.Request, model interface{}, opts ...DecodeOptions) error {
+	raw, err := readJSONBody(r, opts...)
+	if err != nil {
+		return err
+	}
+
+	var decodeOpts DecodeOptions
+	if len(opts) > 0 {
+		decodeOpts = opts[0]
+	}
+	trans := requestTranslator(r)
+	if err := decodeJSON(raw, model, decodeOpts.AllowUnknownFields, trans); err != nil {
+		return err
+	}
+
+	if err := BodyValidate.Struct(model); err != nil {
+		return validationErrorIn(trans, err)
+	}
+	return nil
+}
+
+// readJSONBody : Check the Content-Type and read one JSON value of at most MaxBytes
+func readJSONBody(r *http.Request, opts ...DecodeOptions) (json.RawMessage, error) {
+	var decodeOpts DecodeOptions
+	if len(opts) > 0 {
+		decodeOpts = opts[0]
+	}
+	if decodeOpts.MaxBytes <= 0 {
+		decodeOpts.MaxBytes = defaultMaxBodyBytes
+	}
+
+	if !decodeOpts.AllowAnyContentType {
+		contentType := r.Header.Get("Content-Type")
+		mediaType, _, err := mime.ParseMediaType(contentType)
+		if err != nil || (mediaType != "application/json" && !strings.HasSuffix(mediaType, "+json")) {
+			return nil, fmt.Errorf("%w: expected application/json, got '%s'", ErrUnsupportedMediaType, contentType)
+		}
+	}
+
+	if r.Body == nil {
+		return nil, &ValidationError{Message: "Request Body: empty body"}
+	}
+
+	decoder := json.NewDecoder(http.MaxBytesReader(nil, r.Body, decodeOpts.MaxBytes))
+	var raw json.RawMessage
+	if err := decoder.Decode(&raw); err != nil {
+		var maxBytesError *http.MaxBytesError
+		switch {
+		case errors.As(err, &maxBytesError):
+			return nil, fmt.Errorf("%w: limit is %d bytes", ErrBodyTooLarge, maxBytesError.Limit)
+		case errors.Is(err, io.EOF):
+			return nil, &ValidationError{Message: "Request Body: empty body"}
+		default:
+			return nil, &ValidationError{Message: "Request Body: Invalid JSON format"}
+		}
+	}
+
+	// A second value after the first one is a malformed body, not something to ignore
+	if _, err := decoder.Token(); !errors.Is(err, io.EOF) {
+		return nil, &ValidationError{Message: "Request Body: unexpected data after the JSON value"}
+	}
+	return raw, nil
+}
+
+// decodeJSON : Decode into the model, turning unknown fields and type mismatches into field errors
+func decodeJSON(raw json.RawMessage, model interface{}, allowUnknownFields bool, trans ut.Translator) error {
+	decoder := json.NewDecoder(strings.NewReader(string(raw)))
+	if !allowUnknownFields {
+		decoder.DisallowUnknownFields()
+	}
+
+	err := decoder.Decode(model)
+	if err == nil {
+		return nil
+	}
+
+	var typeError *json.UnmarshalTypeError
+	if errors.As(err, &typeError) {
+		return &ValidationError{Message: translateMessage(trans, messageValidationFailed), Fields: []FieldError{{
+			Field:   typeError.Field,
+			Tag:     "type",
+			Param:   typeError.Type.String(),
+			Message: translateMessage(trans, messageInvalidType, typeError.Field, typeError.Type.String()),
+		}}}
+	}
+
+	// encoding/json has no typed error for unknown fields, only this message
+	if field, found := strings.CutPrefix(err.Error(), "json: unknown field "); found {
+		field = strings.Trim(field, `"`)
+		return &ValidationError{Message: translateMessage(trans, messageValidationFailed), Fields: []FieldError{{
+			Field:   field,
+			Tag:     "unknown",
+			Message: translateMessage(trans, messageUnknownField, field),
+		}}}
+	}
+
+	return &ValidationError{Message: "Request Body: Invalid JSON format"}
+}
+
+// validateObjectId : `objectid` accepts a 24 character hex string or a non-zero primitive.ObjectID
+func validateObjectId(fl validator.FieldLevel) bool {
+	switch value := fl.Field().Interface().(type) {
+	case string:
+		return IsValidObjectID(value)
+	case primitive.ObjectID:
+		return !value.IsZero()
+	}
+	return false
+}
+
+// validateSlug : `slug` accepts lowercase words joined by single hyphens, e.g. "my-first-song"
+func validateSlug(fl validator.FieldLevel) bool {
+	value, ok := fl.Field().Interface().(string)
+	return ok && slugPattern.MatchString(value)
+}
+
+// validateBilingual : `bilingual` needs text in at least one of BilingualLanguages, `bilingual=all` in every one.
+// Works on map[string]string and on structs whose json tags are the language codes.
+func validateBilingual(fl validator.FieldLevel) bool {
+	texts := map[string]string{}
+
+	field := fl.Field()
+	switch field.Kind() {
+	case reflect.Map:
+		if field.Type().Key().Kind() != reflect.String || field.Type().Elem().Kind() != reflect.String {
+			return false
+		}
+		for _, key := range field.MapKeys() {
+			texts[key.String()] = field.MapIndex(key).String()
+		}
+	case reflect.Struct:
+		for i := 0; i < field.NumField(); i++ {
+			structField := field.Type().Field(i)
+			if structField.IsExported() && field.Field(i).Kind() == reflect.String {
+				texts[tagName(structField.Tag.Get("json"), strings.ToLower(structField.Name))] = field.Field(i).String()
+			}
+		}
+	default:
+		return false
+	}
+
+	filled := 0
+	for _, language := range BilingualLanguages {
+		if strings.TrimSpace(texts[language]) != "" {
+			filled++
+		}
+	}
+
+	if fl.Param() == "all" {
+		return filled == len(BilingualLanguages)
+	}
+	return filled > 0
+}
@@ -0,0 +1,304 @@
+package mongora
+
+import (
+	"errors"
+	"fmt"
+	"github.com/go-playground/locales"
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/my"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	enTranslations "github.com/go-playground/validator/v10/translations/en"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Translation keys for the messages mongora writes itself, prefixed so they never clash with validator tags
+const (
+	messageValidationFailed = "mongora.validation_failed"
+	messageUnknownField     = "mongora.unknown_field"
+	messageInvalidType      = "mongora.invalid_type"
+	messageFailedTag        = "mongora.failed_tag"
+)
+
+var (
+	translatorMutex     sync.RWMutex
+	universalTranslator = ut.New(en.New(), en.New())
+	englishTranslator   ut.Translator
+)
+
+// englishMessages : English text for mongora's own messages and custom validators.
+// Built-in validator tags use the validator's default English translations.
+var englishMessages = map[string]string{
+	messageValidationFailed: "Request Body validation error",
+	messageUnknownField:     "{0} is not an accepted field",
+	messageInvalidType:      "{0} must be of type {1}",
+	messageFailedTag:        "{0} failed the '{1}' check",
+	"objectid":              "{0} must be a valid ObjectID",
+	"slug":                  "{0} must only contain lowercase letters, digits and hyphens",
+	"bilingual":             "{0} must have text in at least one language",
+}
+
+// myanmarMessages : Myanmar text for mongora's messages, the custom validators and the common built-in tags.
+// A "-string", "-number" or "-items" suffix gives the message for that kind of field, like the English min/max/len.
+var myanmarMessages = map[string]string{
+	messageValidationFailed: "ပေးပို့သော အချက်အလက်များ မှန်ကန်မှု မရှိပါ",
+	messageUnknownField:     "{0} ကို လက်မခံပါ",
+	messageInvalidType:      "{0} သည် {1} အမျိုးအစား ဖြစ်ရပါမည်",
+	messageFailedTag:        "{0} သည် '{1}' စစ်ဆေးမှုကို မအောင်မြင်ပါ",
+	"objectid":              "{0} သည် မှန်ကန်သော ObjectID ဖြစ်ရပါမည်",
+	"slug":                  "{0} တွင် အင်္ဂလိပ် စာလုံးအသေး၊ ဂဏန်းနှင့် တုံးတို (-) များသာ ပါဝင်ရပါမည်",
+	"bilingual":             "{0} ကို အနည်းဆုံး ဘာသာစကား တစ်ခုဖြင့် ဖြည့်ရပါမည်",
+	"required":              "{0} ကို ဖြည့်ရန် လိုအပ်ပါသည်",
+	"min-string":            "{0} တွင် အနည်းဆုံး စာလုံး {1} လုံး ရှိရပါမည်",
+	"min-number":            "{0} သည် အနည်းဆုံး {1} ဖြစ်ရပါမည်",
+	"min-items":             "{0} တွင် အနည်းဆုံး {1} ခု ပါဝင်ရပါမည်",
+	"max-string":            "{0} တွင် စာလုံး {1} လုံးထက် မပိုရပါ",
+	"max-number":            "{0} သည် အများဆုံး {1} ဖြစ်ရပါမည်",
+	"max-items":             "{0} တွင် {1} ခုထက် မပိုရပါ",
+	"len-string":            "{0} တွင် စာလုံး {1} လုံး ရှိရပါမည်",
+	"len-number":            "{0} သည် {1} နှင့် ညီရပါမည်",
+	"len-items":             "{0} တွင် {1} ခု ပါဝင်ရပါမည်",
+	"eq":                    "{0} သည် {1} နှင့် တူညီရပါမည်",
+	"ne":                    "{0} သည် {1} နှင့် မတူရပါ",
+	"gt":                    "{0} သည် {1} ထက် ကြီးရပါမည်",
+	"gte":                   "{0} သည် {1} နှင့် ညီ သို့မဟုတ် ကြီးရပါမည်",
+	"lt":                    "{0} သည် {1} ထက် ငယ်ရပါမည်",
+	"lte":                   "{0} သည် {1} နှင့် ညီ သို့မဟုတ် ငယ်ရပါမည်",
+	"oneof":                 "{0} သည် [{1}] ထဲမှ တစ်ခု ဖြစ်ရပါမည်",
+	"email":                 "{0} သည် မှန်ကန်သော အီးမေးလ်လိပ်စာ ဖြစ်ရပါမည်",
+	"url":                   "{0} သည် မှန်ကန်သော URL ဖြစ်ရပါမည်",
+	"numeric":               "{0} သည် ဂဏန်း ဖြစ်ရပါမည်",
+	"number":                "{0} သည် ဂဏန်း ဖြစ်ရပါမည်",
+	"alpha":                 "{0} တွင် စာလုံးများသာ ပါဝင်ရပါမည်",
+	"alphanum":              "{0} တွင် စာလုံးနှင့် ဂဏန်းများသာ ပါဝင်ရပါမည်",
+	"uuid":                  "{0} သည် မှန်ကန်သော UUID ဖြစ်ရပါမည်",
+	"datetime":              "{0} သည် {1} ပုံစံနှင့် ကိုက်ညီရပါမည်",
+	"unique":                "{0} တွင် ထပ်နေသော တန်ဖိုးများ မပါရပါ",
+}
+
+func init() {
+	englishTranslator, _ = universalTranslator.GetTranslator("en")
+	if err := enTranslations.RegisterDefaultTranslations(BodyValidate, englishTranslator); err != nil {
+		panic(fmt.Sprintf("mongora: registering english validation messages: %v", err))
+	}
+	if err := registerMessages(englishTranslator, englishMessages); err != nil {
+		panic(fmt.Sprintf("mongora: registering english validation messages: %v", err))
+	}
+
+	err := RegisterValidationLanguage(my.New(), func(_ *validator.Validate, trans ut.Translator) error {
+		return registerMessages(trans, myanmarMessages)
+	})
+	if err != nil {
+		panic(fmt.Sprintf("mongora: registering myanmar validation messages: %v", err))
+	}
+}
+
+// RegisterValidationLanguage : Make a language available to Accept-Language negotiation. register adds the
+// messages, e.g. a validator/v10/translations package's RegisterDefaultTranslations. Messages it leaves out
+// fall back to English.
+func RegisterValidationLanguage(locale locales.Translator, register func(v *validator.Validate, trans ut.Translator) error) error {
+	translatorMutex.Lock()
+	defer translatorMutex.Unlock()
+
+	if err := universalTranslator.AddTranslator(locale, true); err != nil {
+		return err
+	}
+	trans, _ := universalTranslator.GetTranslator(locale.Locale())
+	return register(BodyValidate, trans)
+}
+
+// RegisterValidationMessage : Add or replace the message for one validation tag in a registered language,
+// "{0}" is the field and "{1}" the tag parameter. Suffix the tag with "-string", "-number" or "-items"
+// for a message that only applies to that kind of field, e.g. "min-string".
+func RegisterValidationMessage(locale string, tag string, message string) error {
+	translatorMutex.Lock()
+	defer translatorMutex.Unlock()
+
+	trans, found := universalTranslator.GetTranslator(locale)
+	if !found {
+		return fmt.Errorf("mongora: validation language %s is not registered", locale)
+	}
+	return registerMessages(trans, map[string]string{tag: message})
+}
+
+// ValidationTranslator : Best registered translator for an Accept-Language header, English when nothing matches
+func ValidationTranslator(acceptLanguage string) ut.Translator {
+	translatorMutex.RLock()
+	defer translatorMutex.RUnlock()
+
+	for _, locale := range acceptedLocales(acceptLanguage) {
+		if trans, found := universalTranslator.GetTranslator(locale); found {
+			return trans
+		}
+	}
+	return englishTranslator
+}
+
+// LocalizeError : Translate the field messages of a validation error into the request's language,
+// any other error is returned unchanged
+func LocalizeError(err error, r *http.Request) error {
+	var validationError *ValidationError
+	if !errors.As(err, &validationError) || validationError.cause == nil {
+		return err
+	}
+	return validationErrorIn(requestTranslator(r), validationError.cause)
+}
+
+func requestTranslator(r *http.Request) ut.Translator {
+	if r == nil {
+		return englishTranslator
+	}
+	return ValidationTranslator(r.Header.Get("Accept-Language"))
+}
+
+// registerMessages : Add the messages to the translator and route the tags among them to it
+func registerMessages(trans ut.Translator, messages map[string]string) error {
+	for key, message := range messages {
+		if strings.HasPrefix(key, "mongora.") {
+			if err := trans.Add(key, message, true); err != nil {
+				return err
+			}
+			continue
+		}
+
+		err := BodyValidate.RegisterTranslation(messageTag(key), trans, func(trans ut.Translator) error {
+			return trans.Add(key, message, true)
+		}, translateTag)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// translateTag : Prefer the message for the field's kind, e.g. "min-string", over the plain tag message
+func translateTag(trans ut.Translator, fieldError validator.FieldError) string {
+	if suffix := kindSuffix(fieldError.Kind()); suffix != "" {
+		if message, err := trans.T(fieldError.Tag()+suffix, fieldError.Field(), fieldError.Param()); err == nil {
+			return message
+		}
+	}
+
+	message, err := trans.T(fieldError.Tag(), fieldError.Field(), fieldError.Param())
+	if err != nil {
+		return fieldError.Error()
+	}
+	return message
+}
+
+// kindSuffixes : Message key suffixes for the kinds of field whose size is measured differently
+var kindSuffixes = []string{"-string", "-number", "-items"}
+
+// messageTag : The validation tag a message key belongs to, "min-string" belongs to "min"
+func messageTag(key string) string {
+	for _, suffix := range kindSuffixes {
+		if tag, found := strings.CutSuffix(key, suffix); found && tag != "" {
+			return tag
+		}
+	}
+	return key
+}
+
+func kindSuffix(kind reflect.Kind) string {
+	switch kind {
+	case reflect.String:
+		return "-string"
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return "-items"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "-number"
+	}
+	return ""
+}
+
+// translateMessage : Look up one of mongora's messages, falling back to English
+func translateMessage(trans ut.Translator, key string, params ...string) string {
+	if message, err := trans.T(key, params...); err == nil {
+		return message
+	}
+	message, _ := englishTranslator.T(key, params...)
+	return message
+}
+
+// validationErrorIn : Build a ValidationError from validator output with messages in the translator's language
+func validationErrorIn(trans ut.Translator, err error) error {
+	var validationErrors validator.ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		return &ValidationError{Message: fmt.Sprintf("%s: %v", translateMessage(trans, messageValidationFailed), err), cause: err}
+	}
+
+	fields := make([]FieldError, 0, len(validationErrors))
+	for _, fieldError := range validationErrors {
+		// Drop the struct name so the path matches the request body, e.g. "title.en"
+		field := fieldError.Namespace()
+		if _, path, found := strings.Cut(field, "."); found {
+			field = path
+		}
+
+		// Tags without a message in this language or in English get a generic one instead of the raw validator text
+		message := fieldError.Translate(trans)
+		if message == fieldError.Error() {
+			message = fieldError.Translate(englishTranslator)
+		}
+		if message == fieldError.Error() {
+			message = translateMessage(trans, messageFailedTag, fieldError.Field(), fieldError.Tag())
+		}
+
+		fields = append(fields, FieldError{
+			Field:   field,
+			Tag:     fieldError.Tag(),
+			Param:   fieldError.Param(),
+			Message: message,
+		})
+	}
+	return &ValidationError{Message: translateMessage(trans, messageValidationFailed), Fields: fields, cause: err}
+}
+
+// acceptedLocales : Locales from an Accept-Language header by preference, "my-mm" gives "my_MM" then "my"
+func acceptedLocales(acceptLanguage string) []string {
+	type weightedLocale struct {
+		locale string
+		weight float64
+	}
+
+	var weighted []weightedLocale
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if tag == "" || tag == "*" {
+			continue
+		}
+
+		weight := 1.0
+		if quality, found := strings.CutPrefix(strings.TrimSpace(params), "q="); found {
+			if parsed, err := strconv.ParseFloat(quality, 64); err == nil {
+				weight = parsed
+			}
+		}
+		// q=0 marks the language as not acceptable
+		if weight <= 0 {
+			continue
+		}
+
+		language, region, hasRegion := strings.Cut(strings.ReplaceAll(tag, "-", "_"), "_")
+		language = strings.ToLower(language)
+		if hasRegion {
+			weighted = append(weighted, weightedLocale{locale: language + "_" + strings.ToUpper(region), weight: weight})
+		}
+		weighted = append(weighted, weightedLocale{locale: language, weight: weight})
+	}
+
+	sort.SliceStable(weighted, func(i, j int) bool { return weighted[i].weight > weighted[j].weight })
+
+	locales := make([]string, 0, len(weighted))
+	for _, entry := range weighted {
+		locales = append(locales, entry.locale)
+	}
+	return locales
+}
@@ -0,0 +1,39 @@
+package mongora
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAcceptedLocales(t *testing.T) {
+	tests := []struct {
+		header string
+		want   []string
+	}{
+		{header: "", want: []string{}},
+		{header: "my", want: []string{"my"}},
+		{header: "my-mm", want: []string{"my_MM", "my"}},
+		{header: "en;q=0.5, my;q=0.9", want: []string{"my", "en"}},
+		{header: "fr, *;q=0.1", want: []string{"fr"}},
+		{header: "my;q=0, en", want: []string{"en"}},
+		{header: "my;q=0.0, en;q=0.3", want: []string{"en"}},
+		{header: "my;q=bad", want: []string{"my"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			if got := acceptedLocales(tt.header); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("acceptedLocales(%q) = %v, want %v", tt.header, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidationTranslatorSkipsUnacceptableLanguages(t *testing.T) {
+	if got := ValidationTranslator("my;q=0, en").Locale(); got != "en" {
+		t.Errorf("locale = %s, want en", got)
+	}
+	if got := ValidationTranslator("en;q=0.2, my").Locale(); got != "my" {
+		t.Errorf("locale = %s, want my", got)
+	}
+}
@@ -0,0 +1,91 @@
+package mongora
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// UpdateWithVersion : Apply the update only if the stored version still equals expectedVersion.
+// Returns ErrVersionConflict when the document exists with another version and ErrNotFound when it is gone.
+func UpdateWithVersion(ctx context.Context, collection *mongo.Collection, filter interface{}, expectedVersion int64, update interface{}) (bson.M, error) {
+	return derefDocument(updateWithVersion[bson.M](ctx, collection, filter, expectedVersion, update))
+}
+
+// UpdateWithVersion : Typed variant of UpdateWithVersion
+func (r *Repository[T]) UpdateWithVersion(ctx context.Context, filter interface{}, expectedVersion int64, update interface{}) (*T, error) {
+	return updateWithVersion[T](ctx, r.collection, filter, expectedVersion, update)
+}
+
+// RetryOnConflict : Re-run a read-modify-write function while it fails with ErrVersionConflict, at most maxAttempts times.
+// fn always runs at least once, even when maxAttempts is zero or negative.
+func RetryOnConflict(ctx context.Context, maxAttempts int, fn func(ctx context.Context) error) error {
+	maxAttempts = max(maxAttempts, 1)
+
+	var err error
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		if err = fn(ctx); !errors.Is(err, ErrVersionConflict) {
+			return err
+		}
+		if ctx.Err() != nil {
+			return err
+		}
+	}
+	return err
+}
+
+// GetVersion : Read the version of a document decoded as bson.M, 0 when the field is missing
+func GetVersion(collection *mongo.Collection, document bson.M) int64 {
+	switch version := document[GetCollectionOptions(collection).VersionField].(type) {
+	case int32:
+		return int64(version)
+	case int64:
+		return version
+	case float64:
+		return int64(version)
+	default:
+		return 0
+	}
+}
+
+func updateWithVersion[T any](ctx context.Context, collection *mongo.Collection, filter interface{}, expectedVersion int64, update interface{}) (*T, error) {
+	versionField := GetCollectionOptions(collection).VersionField
+	if versionField == "" {
+		return nil, fmt.Errorf("mongora: no version field configured for %s", collectionKey(collection))
+	}
+
+	ctx, cancel := withTimeout(ctx, collection)
+	defer cancel()
+
+	// stampUpdate adds the $inc of the version field
+	update, err := stampUpdate(ctx, collection, update, false)
+	if err != nil {
+		return nil, err
+	}
+
+	scoped := scopeFilter(ctx, collection, filter)
+	versioned := andFilter(scoped, bson.D{{Key: versionField, Value: expectedVersion}})
+
+	var result T
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	err = collection.FindOneAndUpdate(ctx, versioned, update, opts).Decode(&result)
+	if err == nil {
+		return &result, nil
+	}
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, translateError(err)
+	}
+
+	// Nothing matched, tell a stale version apart from a missing document
+	count, countErr := collection.CountDocuments(ctx, scoped, options.Count().SetLimit(1))
+	if countErr != nil {
+		return nil, translateError(countErr)
+	}
+	if count > 0 {
+		return nil, ErrVersionConflict
+	}
+	return nil, translateError(err)
+}
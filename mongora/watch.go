@@ -0,0 +1,261 @@
+package mongora
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"sync"
+	"time"
+)
+
+// Change stream operation types delivered to handlers
+const (
+	OperationInsert  = "insert"
+	OperationUpdate  = "update"
+	OperationReplace = "replace"
+	OperationDelete  = "delete"
+)
+
+// UpdateDescription : Fields changed by an update event
+type UpdateDescription struct {
+	UpdatedFields bson.M   `bson:"updatedFields"`
+	RemovedFields []string `bson:"removedFields"`
+}
+
+// ChangeEvent : A change stream event with the full document decoded into T
+type ChangeEvent[T any] struct {
+	ResumeToken       bson.Raw            `bson:"_id"`
+	OperationType     string              `bson:"operationType"`
+	ClusterTime       primitive.Timestamp `bson:"clusterTime"`
+	DocumentKey       bson.M              `bson:"documentKey"`
+	FullDocument      *T                  `bson:"fullDocument,omitempty"`
+	UpdateDescription *UpdateDescription  `bson:"updateDescription,omitempty"`
+}
+
+// ResumeTokenStore : Persists the last processed resume token so a restarted watcher continues where it stopped
+type ResumeTokenStore interface {
+	Load(ctx context.Context, key string) (bson.Raw, error)
+	Save(ctx context.Context, key string, token bson.Raw) error
+}
+
+// WatchOptions : Resume, lookup and reconnect behaviour for Watch, zero values use the defaults
+type WatchOptions struct {
+	// Key identifies the watcher in the token store, defaults to "database.collection"
+	Key string
+
+	// Store persists resume tokens, nothing is persisted when nil
+	Store ResumeTokenStore
+
+	// FullDocument looks up the current document for update events
+	FullDocument bool
+
+	// MinBackoff and MaxBackoff bound the reconnect delay, default 500ms and 30s
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+}
+
+// Watch : Deliver every change on the collection to handler until ctx is done or the handler returns an error.
+// The stream reconnects with exponential backoff and resumes after the last token saved to the store.
+// Errors no reconnect can fix, e.g. a standalone server or an invalid pipeline, are returned at once.
+func Watch[T any](ctx context.Context, collection *mongo.Collection, pipeline interface{}, handler func(ctx context.Context, event ChangeEvent[T]) error, opts ...WatchOptions) error {
+	watchOpts := resolveWatchOptions(collection, opts)
+	if pipeline == nil {
+		pipeline = mongo.Pipeline{}
+	}
+
+	var resumeToken bson.Raw
+	if watchOpts.Store != nil {
+		token, err := watchOpts.Store.Load(ctx, watchOpts.Key)
+		if err != nil {
+			return fmt.Errorf("mongora: loading resume token: %w", err)
+		}
+		resumeToken = token
+	}
+
+	backoff := watchOpts.MinBackoff
+	for {
+		streamOpts := options.ChangeStream()
+		if watchOpts.FullDocument {
+			streamOpts.SetFullDocument(options.UpdateLookup)
+		}
+		if len(resumeToken) > 0 {
+			streamOpts.SetResumeAfter(resumeToken)
+		}
+
+		stream, err := collection.Watch(ctx, pipeline, streamOpts)
+		if err == nil {
+			for stream.Next(ctx) {
+				var event ChangeEvent[T]
+				if err := stream.Decode(&event); err != nil {
+					_ = stream.Close(context.Background())
+					return fmt.Errorf("mongora: decoding change event: %w", err)
+				}
+
+				if err := handler(ctx, event); err != nil {
+					_ = stream.Close(context.Background())
+					return err
+				}
+
+				resumeToken = stream.ResumeToken()
+				if watchOpts.Store != nil {
+					if err := watchOpts.Store.Save(ctx, watchOpts.Key, resumeToken); err != nil {
+						_ = stream.Close(context.Background())
+						return fmt.Errorf("mongora: saving resume token: %w", err)
+					}
+				}
+				backoff = watchOpts.MinBackoff
+			}
+
+			err = stream.Err()
+			_ = stream.Close(context.Background())
+
+			// A stream that ends without an error was invalidated, e.g. the collection was dropped
+			if err == nil && ctx.Err() == nil {
+				return nil
+			}
+		}
+
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if isNonResumableWatchError(err) {
+			return translateError(err)
+		}
+
+		// Wait before reconnecting, doubling the delay after every consecutive failure
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, watchOpts.MaxBackoff)
+	}
+}
+
+// WatchChannel : Same as Watch but delivers events on a channel, the error channel receives the final error
+// and both channels are closed when watching stops.
+func WatchChannel[T any](ctx context.Context, collection *mongo.Collection, pipeline interface{}, opts ...WatchOptions) (<-chan ChangeEvent[T], <-chan error) {
+	events := make(chan ChangeEvent[T])
+	errs := make(chan error, 1)
+
+	go func() {
+		defer close(events)
+		defer close(errs)
+
+		err := Watch(ctx, collection, pipeline, func(ctx context.Context, event ChangeEvent[T]) error {
+			select {
+			case events <- event:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}, opts...)
+
+		if err != nil && !errors.Is(err, context.Canceled) {
+			errs <- err
+		}
+	}()
+
+	return events, errs
+}
+
+func resolveWatchOptions(collection *mongo.Collection, opts []WatchOptions) WatchOptions {
+	var watchOpts WatchOptions
+	if len(opts) > 0 {
+		watchOpts = opts[0]
+	}
+
+	if watchOpts.Key == "" {
+		watchOpts.Key = collectionKey(collection)
+	}
+	if watchOpts.MinBackoff <= 0 {
+		watchOpts.MinBackoff = 500 * time.Millisecond
+	}
+	if watchOpts.MaxBackoff < watchOpts.MinBackoff {
+		watchOpts.MaxBackoff = max(30*time.Second, watchOpts.MinBackoff)
+	}
+	return watchOpts
+}
+
+// isNonResumableWatchError : Errors that reconnecting cannot fix, such as a resume token that fell off the oplog
+func isNonResumableWatchError(err error) bool {
+	var serverError mongo.ServerError
+	if !errors.As(err, &serverError) {
+		return false
+	}
+
+	// 286 ChangeStreamHistoryLost, 280 ChangeStreamFatalError, 136 CappedPositionLost, 13 Unauthorized,
+	// 40573 change streams on a standalone server, 40324 unknown pipeline stage, 2 BadValue,
+	// 9 FailedToParse, 14 TypeMismatch and 73 InvalidNamespace for a malformed pipeline or collection
+	for _, code := range []int{286, 280, 136, 13, 40573, 40324, 2, 9, 14, 73} {
+		if serverError.HasErrorCode(code) {
+			return true
+		}
+	}
+	return serverError.HasErrorLabel("NonResumableChangeStreamError")
+}
+
+// MemoryResumeTokenStore : In-process token store, tokens are lost on restart
+type MemoryResumeTokenStore struct {
+	mutex  sync.RWMutex
+	tokens map[string]bson.Raw
+}
+
+func NewMemoryResumeTokenStore() *MemoryResumeTokenStore {
+	return &MemoryResumeTokenStore{tokens: map[string]bson.Raw{}}
+}
+
+func (s *MemoryResumeTokenStore) Load(_ context.Context, key string) (bson.Raw, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.tokens[key], nil
+}
+
+func (s *MemoryResumeTokenStore) Save(_ context.Context, key string, token bson.Raw) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.tokens[key] = token
+	return nil
+}
+
+// CollectionResumeTokenStore : Persist tokens in a MongoDB collection, one document per watcher key
+type CollectionResumeTokenStore struct {
+	collection *mongo.Collection
+}
+
+func NewCollectionResumeTokenStore(collection *mongo.Collection) *CollectionResumeTokenStore {
+	return &CollectionResumeTokenStore{collection: collection}
+}
+
+func (s *CollectionResumeTokenStore) Load(ctx context.Context, key string) (bson.Raw, error) {
+	ctx, cancel := withTimeout(ctx, s.collection)
+	defer cancel()
+
+	var stored struct {
+		Token bson.Raw `bson:"token"`
+	}
+	err := s.collection.FindOne(ctx, bson.D{{Key: "_id", Value: key}}).Decode(&stored)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return stored.Token, nil
+}
+
+func (s *CollectionResumeTokenStore) Save(ctx context.Context, key string, token bson.Raw) error {
+	ctx, cancel := withTimeout(ctx, s.collection)
+	defer cancel()
+
+	update := bson.D{{Key: "$set", Value: bson.D{
+		{Key: "token", Value: token},
+		{Key: "updated_at", Value: primitive.NewDateTimeFromTime(time.Now())},
+	}}}
+	_, err := s.collection.UpdateOne(ctx, bson.D{{Key: "_id", Value: key}}, update, options.Update().SetUpsert(true))
+	return translateError(err)
+}